@@ -2,45 +2,82 @@ package main
 
 import (
 	"bufio"
+	"bytes"
+	"flag"
 	"fmt"
 	"log"
+	"math/bits"
 	"os"
+	"sort"
 )
 
+type TypeSet uint64
+
+func (s TypeSet) contains(itemType byte) bool {
+	return s&(1<<getPriority(itemType)) != 0
+}
+
+func (s TypeSet) types() []byte {
+	var types []byte
+	for rest := s; rest != 0; rest &= rest - 1 {
+		types = append(types, getItemType(bits.TrailingZeros64(uint64(rest))))
+	}
+	return types
+}
+
+func (s TypeSet) priority() int {
+	priority := 0
+	for rest := s; rest != 0; rest &= rest - 1 {
+		priority += bits.TrailingZeros64(uint64(rest))
+	}
+	return priority
+}
+
 type Rucksack struct {
 	firstCompartment  []byte
 	secondCompartment []byte
-	typesSet          []bool
-	errorType         byte
+	typesSet          TypeSet
+	errorTypes        TypeSet
 }
 
-func newRucksack(contents []byte) *Rucksack {
+func newRucksack(contents []byte) (*Rucksack, error) {
 	compartmentSize := len(contents) / 2
-	firstCompartment := contents[:compartmentSize]
-	secondCompartment := contents[compartmentSize:]
-
-	typesSet := make([]bool, 'z'-'A'+1)
-	var errorType byte
+	firstCompartment := make([]byte, compartmentSize)
+	secondCompartment := make([]byte, len(contents)-compartmentSize)
+	copy(firstCompartment, contents[:compartmentSize])
+	copy(secondCompartment, contents[compartmentSize:])
 
-	for _, item := range firstCompartment {
-		typesSet[item-'A'] = true
+	firstSet, err := getTypeSet(firstCompartment, 0)
+	if err != nil {
+		return nil, err
 	}
 
-	for _, item := range secondCompartment {
-		if typesSet[item-'A'] {
-			errorType = item
-			continue
-		}
-
-		typesSet[item-'A'] = true
+	secondSet, err := getTypeSet(secondCompartment, compartmentSize)
+	if err != nil {
+		return nil, err
 	}
 
 	return &Rucksack{
 		firstCompartment:  firstCompartment,
 		secondCompartment: secondCompartment,
-		typesSet:          typesSet,
-		errorType:         errorType,
+		typesSet:          firstSet | secondSet,
+		errorTypes:        firstSet & secondSet,
+	}, nil
+}
+
+func getTypeSet(items []byte, offset int) (TypeSet, error) {
+	var set TypeSet
+	for i, item := range items {
+		if !isItemType(item) {
+			return 0, fmt.Errorf("invalid item type %q at column %d", item, offset+i+1)
+		}
+		set |= 1 << getPriority(item)
 	}
+	return set, nil
+}
+
+func isItemType(item byte) bool {
+	return item >= 'a' && item <= 'z' || item >= 'A' && item <= 'Z'
 }
 
 func getPriority(itemType byte) int {
@@ -51,54 +88,328 @@ func getPriority(itemType byte) int {
 	return int(itemType - 'A' + 27)
 }
 
-func getGroupType(group []*Rucksack) byte {
-	for itemType := byte('A'); itemType <= 'Z'; itemType += 1 {
-		if isGroupType(itemType, group) {
-			return itemType
+func getItemType(priority int) byte {
+	if priority <= 26 {
+		return byte('a' + priority - 1)
+	}
+
+	return byte('A' + priority - 27)
+}
+
+func getGroupTypes(group []*Rucksack) TypeSet {
+	groupTypes := ^TypeSet(0)
+	for _, rucksack := range group {
+		groupTypes &= rucksack.typesSet
+	}
+	return groupTypes
+}
+
+type Swap struct {
+	rucksack int
+	first    byte
+	second   byte
+}
+
+func (s *Swap) String() string {
+	return fmt.Sprintf("rucksack %d: swap %c from first with %c from second compartment",
+		s.rucksack+1, s.first, s.second)
+}
+
+func countTypes(items []byte) map[byte]int {
+	counts := make(map[byte]int)
+	for _, item := range items {
+		counts[item] += 1
+	}
+	return counts
+}
+
+func planSwaps(index int, rucksack *Rucksack) ([]*Swap, bool) {
+	firstCounts := countTypes(rucksack.firstCompartment)
+	secondCounts := countTypes(rucksack.secondCompartment)
+	types := rucksack.typesSet.types()
+	target := len(rucksack.firstCompartment)
+	unreachable := len(rucksack.firstCompartment) + len(rucksack.secondCompartment) + 1
+
+	costs := make([][]int, len(types)+1)
+	for i := range costs {
+		costs[i] = make([]int, target+1)
+		for j := range costs[i] {
+			costs[i][j] = unreachable
 		}
 	}
+	costs[0][0] = 0
 
-	for itemType := byte('a'); itemType <= 'z'; itemType += 1 {
-		if isGroupType(itemType, group) {
-			return itemType
+	for i, itemType := range types {
+		total := firstCounts[itemType] + secondCounts[itemType]
+		for j, cost := range costs[i] {
+			if cost == unreachable {
+				continue
+			}
+			if keepSecond := cost + firstCounts[itemType]; keepSecond < costs[i+1][j] {
+				costs[i+1][j] = keepSecond
+			}
+			if j+total <= target {
+				if keepFirst := cost + secondCounts[itemType]; keepFirst < costs[i+1][j+total] {
+					costs[i+1][j+total] = keepFirst
+				}
+			}
 		}
 	}
 
-	return '0'
+	if costs[len(types)][target] == unreachable {
+		return nil, false
+	}
+
+	var toSecond, toFirst []byte
+	for i, j := len(types)-1, target; i >= 0; i-- {
+		itemType := types[i]
+		total := firstCounts[itemType] + secondCounts[itemType]
+		if j >= total && costs[i][j-total] != unreachable &&
+			costs[i][j-total]+secondCounts[itemType] == costs[i+1][j] {
+			for k := 0; k < secondCounts[itemType]; k++ {
+				toFirst = append(toFirst, itemType)
+			}
+			j -= total
+		} else {
+			for k := 0; k < firstCounts[itemType]; k++ {
+				toSecond = append(toSecond, itemType)
+			}
+		}
+	}
+
+	swaps := make([]*Swap, len(toSecond))
+	for i := range swaps {
+		swaps[i] = &Swap{index, toSecond[i], toFirst[i]}
+	}
+	return swaps, true
 }
 
-func isGroupType(itemType byte, group []*Rucksack) bool {
-	for _, rucksack := range group {
-		if !rucksack.typesSet[itemType-'A'] {
-			return false
+func planRebalancing(rucksacks []*Rucksack) ([]*Swap, []int) {
+	var swaps []*Swap
+	var unbalanced []int
+	for i, rucksack := range rucksacks {
+		rucksackSwaps, found := planSwaps(i, rucksack)
+		if !found {
+			unbalanced = append(unbalanced, i)
+			continue
+		}
+		swaps = append(swaps, rucksackSwaps...)
+	}
+	return swaps, unbalanced
+}
+
+func applySwaps(rucksacks []*Rucksack, swaps []*Swap) ([]*Rucksack, error) {
+	contents := make([][]byte, len(rucksacks))
+	for i, rucksack := range rucksacks {
+		contents[i] = append(append([]byte{}, rucksack.firstCompartment...),
+			rucksack.secondCompartment...)
+	}
+
+	for _, swap := range swaps {
+		items := contents[swap.rucksack]
+		half := len(rucksacks[swap.rucksack].firstCompartment)
+		first := bytes.IndexByte(items[:half], swap.first)
+		second := bytes.IndexByte(items[half:], swap.second)
+		if first < 0 || second < 0 {
+			return nil, fmt.Errorf("cannot apply %v", swap)
 		}
+		items[first], items[half+second] = items[half+second], items[first]
 	}
 
-	return true
+	result := make([]*Rucksack, len(contents))
+	for i, items := range contents {
+		rucksack, err := newRucksack(items)
+		if err != nil {
+			return nil, err
+		}
+		result[i] = rucksack
+	}
+	return result, nil
+}
+
+func getErrorPriorities(rucksacks []*Rucksack) int {
+	total := 0
+	for _, rucksack := range rucksacks {
+		total += rucksack.errorTypes.priority()
+	}
+	return total
+}
+
+func getFixedGroups(rucksacks []*Rucksack, groupSize int) [][]int {
+	var groups [][]int
+	for i := 0; i+groupSize <= len(rucksacks); i += groupSize {
+		group := make([]int, groupSize)
+		for j := range group {
+			group[j] = i + j
+		}
+		groups = append(groups, group)
+	}
+	return groups
+}
+
+func planGroups(rucksacks []*Rucksack, groupSize int) [][]int {
+	groups := planGreedyGroups(rucksacks, groupSize)
+	fixedGroups := getFixedGroups(rucksacks, groupSize)
+	if getGroupsPriority(rucksacks, groups) < getGroupsPriority(rucksacks, fixedGroups) {
+		return fixedGroups
+	}
+	return groups
+}
+
+func planGreedyGroups(rucksacks []*Rucksack, groupSize int) [][]int {
+	grouped := make([]bool, len(rucksacks))
+	var groups [][]int
+
+	for priority := 52; priority >= 1; priority-- {
+		itemType := getItemType(priority)
+		for {
+			var candidates []int
+			for i, rucksack := range rucksacks {
+				if !grouped[i] && rucksack.typesSet.contains(itemType) {
+					candidates = append(candidates, i)
+				}
+			}
+			if len(candidates) < groupSize {
+				break
+			}
+
+			group := pickGroup(rucksacks, candidates, groupSize)
+			for _, i := range group {
+				grouped[i] = true
+			}
+			groups = append(groups, group)
+		}
+	}
+
+	var rest []int
+	for i := range rucksacks {
+		if !grouped[i] {
+			rest = append(rest, i)
+		}
+	}
+	for ; len(rest) >= groupSize; rest = rest[groupSize:] {
+		groups = append(groups, rest[:groupSize])
+	}
+
+	return groups
+}
+
+func pickGroup(rucksacks []*Rucksack, candidates []int, groupSize int) []int {
+	sort.SliceStable(candidates, func(i, j int) bool {
+		return bits.OnesCount64(uint64(rucksacks[candidates[i]].typesSet)) <
+			bits.OnesCount64(uint64(rucksacks[candidates[j]].typesSet))
+	})
+
+	group := []int{candidates[0]}
+	common := rucksacks[candidates[0]].typesSet
+	used := map[int]bool{0: true}
+	for len(group) < groupSize {
+		best := -1
+		var bestCommon TypeSet
+		for i, candidate := range candidates {
+			if used[i] {
+				continue
+			}
+			candidateCommon := common & rucksacks[candidate].typesSet
+			if best == -1 || candidateCommon.priority() > bestCommon.priority() {
+				best = i
+				bestCommon = candidateCommon
+			}
+		}
+		used[best] = true
+		group = append(group, candidates[best])
+		common = bestCommon
+	}
+
+	return group
+}
+
+func getGroupsPriority(rucksacks []*Rucksack, groups [][]int) int {
+	total := 0
+	group := make([]*Rucksack, 0)
+	for _, indexes := range groups {
+		group = group[:0]
+		for _, i := range indexes {
+			group = append(group, rucksacks[i])
+		}
+		total += getGroupTypes(group).priority()
+	}
+	return total
+}
+
+func printPlan(rucksacks []*Rucksack, groupSize int) {
+	swaps, unbalanced := planRebalancing(rucksacks)
+	for _, swap := range swaps {
+		fmt.Println(swap)
+	}
+	for _, i := range unbalanced {
+		fmt.Printf("rucksack %d: cannot be split into equal compartments without shared types\n", i+1)
+	}
+
+	rebalanced, err := applySwaps(rucksacks, swaps)
+	errorHandler(err)
+
+	fixedGroups := getFixedGroups(rucksacks, groupSize)
+	groups := planGroups(rucksacks, groupSize)
+
+	fmt.Printf("swaps: %d\n", len(swaps))
+	fmt.Printf("error priorities: %d -> %d\n",
+		getErrorPriorities(rucksacks), getErrorPriorities(rebalanced))
+	fmt.Printf("group priorities (greedy heuristic): %d -> %d\n",
+		getGroupsPriority(rucksacks, fixedGroups), getGroupsPriority(rucksacks, groups))
+	for _, group := range groups {
+		fmt.Print("group:")
+		for _, i := range group {
+			fmt.Printf(" %d", i+1)
+		}
+		fmt.Println()
+	}
 }
 
 func main() {
+	groupSize := flag.Int("group-size", 3, "number of rucksacks in each group")
+	plan := flag.Bool("plan", false, "print a rebalancing plan and a greedy grouping that never scores below the input order")
+	flag.Parse()
+	if *groupSize < 1 {
+		log.Fatalf("invalid group size %d", *groupSize)
+	}
+
 	f, err := os.Open("input.txt")
 	errorHandler(err)
 	defer f.Close()
 
-	group := make([]*Rucksack, 3)
+	var rucksacks []*Rucksack
+	group := make([]*Rucksack, *groupSize)
 	groupCount := 0
 	errorPriorities := 0
 	groupPriorities := 0
 
 	scanner := bufio.NewScanner(f)
-	for scanner.Scan() {
-		rucksack := newRucksack(scanner.Bytes())
-		errorPriorities += getPriority(rucksack.errorType)
+	for line := 1; scanner.Scan(); line++ {
+		rucksack, err := newRucksack(scanner.Bytes())
+		if err != nil {
+			log.Fatalf("line %d: %v", line, err)
+		}
+
+		rucksacks = append(rucksacks, rucksack)
+		errorPriorities += rucksack.errorTypes.priority()
+		if errorTypes := rucksack.errorTypes.types(); len(errorTypes) > 1 {
+			fmt.Fprintf(os.Stderr, "line %d: shared types %s\n", line, errorTypes)
+		}
+
 		group[groupCount] = rucksack
 		groupCount += 1
-		if groupCount == 3 {
-			groupType := getGroupType(group)
-			groupPriorities += getPriority(groupType)
+		if groupCount == *groupSize {
+			groupPriorities += getGroupTypes(group).priority()
 			groupCount = 0
 		}
 	}
+	errorHandler(scanner.Err())
+
+	if *plan {
+		printPlan(rucksacks, *groupSize)
+		return
+	}
 
 	fmt.Println(errorPriorities)
 	fmt.Println(groupPriorities)
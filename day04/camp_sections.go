@@ -2,27 +2,26 @@ package main
 
 import (
 	"bufio"
+	"flag"
 	"fmt"
 	"log"
 	"os"
 	"strconv"
 	"strings"
+
+	"github.com/davidaf3/advent-of-code-2022/day04/interval"
 )
 
 type Assignment struct {
-	start int
-	end   int
+	interval.Interval
 }
 
 func (a *Assignment) contains(other *Assignment) bool {
-	return a.start <= other.start && a.end >= other.end
+	return a.ContainsInterval(other.Interval)
 }
 
 func (a *Assignment) overlaps(other *Assignment) bool {
-	return a.start <= other.start && a.end >= other.start ||
-		a.start <= other.end && a.end >= other.end ||
-		a.contains(other) ||
-		other.contains(a)
+	return a.Overlaps(other.Interval)
 }
 
 func newAssignmentFromText(text string) *Assignment {
@@ -31,7 +30,7 @@ func newAssignmentFromText(text string) *Assignment {
 	errorHandler(err)
 	end, err := strconv.Atoi(splitted[1])
 	errorHandler(err)
-	return &Assignment{start, end}
+	return &Assignment{interval.Closed(start, end)}
 }
 
 type Pair struct {
@@ -55,26 +54,90 @@ func newPairFromText(text string) *Pair {
 	}
 }
 
+func getIntervals(pairs []*Pair) []interval.Interval {
+	var intervals []interval.Interval
+	for _, pair := range pairs {
+		intervals = append(intervals, pair.first.Interval, pair.second.Interval)
+	}
+	return intervals
+}
+
+func getBounds(intervals []interval.Interval) interval.Interval {
+	bounds := intervals[0]
+	for _, i := range intervals[1:] {
+		if i.Start < bounds.Start {
+			bounds.Start = i.Start
+		}
+		if i.End > bounds.End {
+			bounds.End = i.End
+		}
+	}
+	return bounds
+}
+
+func getAssignmentsTree(pairs []*Pair) *interval.Tree[string] {
+	var entries []interval.Entry[string]
+	for i, pair := range pairs {
+		entries = append(entries,
+			interval.Entry[string]{Interval: pair.first.Interval, Value: fmt.Sprintf("pair %d first", i+1)},
+			interval.Entry[string]{Interval: pair.second.Interval, Value: fmt.Sprintf("pair %d second", i+1)},
+		)
+	}
+	return interval.NewTree(entries)
+}
+
 func main() {
+	mode := flag.String("mode", "pairs", "pairs, coverage, uncovered, most-overlapped, section, graph or dot")
+	section := flag.Int("section", 0, "section to query in section mode")
+	flag.Parse()
+
 	f, err := os.Open("input.txt")
 	errorHandler(err)
 	defer f.Close()
 
-	contained := 0
-	overlapping := 0
+	var pairs []*Pair
 	scanner := bufio.NewScanner(f)
 	for scanner.Scan() {
-		pair := newPairFromText(scanner.Text())
-		if pair.oneContainsOther() {
-			contained += 1
-			overlapping += 1
-		} else if pair.assingmentsOverlap() {
-			overlapping += 1
-		}
+		pairs = append(pairs, newPairFromText(scanner.Text()))
 	}
+	errorHandler(scanner.Err())
 
-	fmt.Println(contained)
-	fmt.Println(overlapping)
+	switch *mode {
+	case "pairs":
+		contained := 0
+		overlapping := 0
+		for _, pair := range pairs {
+			if pair.oneContainsOther() {
+				contained += 1
+				overlapping += 1
+			} else if pair.assingmentsOverlap() {
+				overlapping += 1
+			}
+		}
+
+		fmt.Println(contained)
+		fmt.Println(overlapping)
+	case "coverage":
+		fmt.Println(interval.Merge(getIntervals(pairs)).Len())
+	case "uncovered":
+		intervals := getIntervals(pairs)
+		for _, gap := range interval.Merge(intervals).Gaps(getBounds(intervals)) {
+			fmt.Println(gap)
+		}
+	case "most-overlapped":
+		section, count := interval.MostOverlapped(getIntervals(pairs))
+		fmt.Println(section, count)
+	case "section":
+		for _, entry := range getAssignmentsTree(pairs).Stab(*section) {
+			fmt.Println(entry.Value, entry.Interval)
+		}
+	case "graph":
+		newOverlapGraph(pairs).writeReport(os.Stdout)
+	case "dot":
+		newOverlapGraph(pairs).writeDot(os.Stdout)
+	default:
+		log.Fatalf("unknown mode %q", *mode)
+	}
 }
 
 func errorHandler(err error) {
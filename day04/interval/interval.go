@@ -0,0 +1,169 @@
+package interval
+
+import (
+	"fmt"
+	"sort"
+)
+
+type Interval struct {
+	Start int
+	End   int
+}
+
+func Closed(start, end int) Interval {
+	return Interval{start, end + 1}
+}
+
+func HalfOpen(start, end int) Interval {
+	return Interval{start, end}
+}
+
+func (i Interval) Last() int {
+	return i.End - 1
+}
+
+func (i Interval) Len() int {
+	if i.Empty() {
+		return 0
+	}
+	return i.End - i.Start
+}
+
+func (i Interval) Empty() bool {
+	return i.End <= i.Start
+}
+
+func (i Interval) Contains(x int) bool {
+	return i.Start <= x && x < i.End
+}
+
+func (i Interval) ContainsInterval(other Interval) bool {
+	return other.Empty() || i.Start <= other.Start && i.End >= other.End
+}
+
+func (i Interval) Overlaps(other Interval) bool {
+	return !i.Intersect(other).Empty()
+}
+
+func (i Interval) Intersect(other Interval) Interval {
+	return Interval{max(i.Start, other.Start), min(i.End, other.End)}
+}
+
+func (i Interval) Union(other Interval) []Interval {
+	return Merge([]Interval{i, other})
+}
+
+func (i Interval) String() string {
+	if i.Empty() {
+		return "[]"
+	}
+	return fmt.Sprintf("[%d,%d]", i.Start, i.Last())
+}
+
+type Set []Interval
+
+func Merge(ranges []Interval) Set {
+	sorted := make([]Interval, 0, len(ranges))
+	for _, r := range ranges {
+		if !r.Empty() {
+			sorted = append(sorted, r)
+		}
+	}
+	sort.Slice(sorted, func(i, j int) bool {
+		return sorted[i].Start < sorted[j].Start
+	})
+
+	var merged Set
+	for _, r := range sorted {
+		last := len(merged) - 1
+		if last >= 0 && r.Start <= merged[last].End {
+			merged[last].End = max(merged[last].End, r.End)
+			continue
+		}
+		merged = append(merged, r)
+	}
+
+	return merged
+}
+
+func (s Set) Len() int {
+	total := 0
+	for _, r := range s {
+		total += r.Len()
+	}
+	return total
+}
+
+func (s Set) Gaps(within Interval) []Interval {
+	var gaps []Interval
+	current := within.Start
+	for _, r := range s {
+		if r.End <= current {
+			continue
+		}
+		if r.Start >= within.End {
+			break
+		}
+		if r.Start > current {
+			gaps = append(gaps, Interval{current, r.Start})
+		}
+		current = r.End
+	}
+
+	if current < within.End {
+		gaps = append(gaps, Interval{current, within.End})
+	}
+
+	return gaps
+}
+
+func (s Set) Covered(r Interval) bool {
+	idx := sort.Search(len(s), func(i int) bool {
+		return s[i].End > r.Start
+	})
+	return r.Empty() || idx < len(s) && s[idx].ContainsInterval(r)
+}
+
+func MostOverlapped(ranges []Interval) (int, int) {
+	type event struct {
+		at    int
+		delta int
+	}
+
+	var events []event
+	for _, r := range ranges {
+		if !r.Empty() {
+			events = append(events, event{r.Start, 1}, event{r.End, -1})
+		}
+	}
+	sort.Slice(events, func(i, j int) bool {
+		if events[i].at == events[j].at {
+			return events[i].delta < events[j].delta
+		}
+		return events[i].at < events[j].at
+	})
+
+	point, depth, maxDepth := 0, 0, 0
+	for _, e := range events {
+		depth += e.delta
+		if depth > maxDepth {
+			point, maxDepth = e.at, depth
+		}
+	}
+
+	return point, maxDepth
+}
+
+func min(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
+
+func max(a, b int) int {
+	if a > b {
+		return a
+	}
+	return b
+}
@@ -0,0 +1,80 @@
+package interval
+
+import "sort"
+
+type Entry[T any] struct {
+	Interval Interval
+	Value    T
+}
+
+type node[T any] struct {
+	entry  Entry[T]
+	maxEnd int
+	left   *node[T]
+	right  *node[T]
+}
+
+type Tree[T any] struct {
+	root *node[T]
+	size int
+}
+
+func NewTree[T any](entries []Entry[T]) *Tree[T] {
+	sorted := make([]Entry[T], len(entries))
+	copy(sorted, entries)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].Interval.Start < sorted[j].Interval.Start
+	})
+	return &Tree[T]{buildNode(sorted), len(sorted)}
+}
+
+func buildNode[T any](entries []Entry[T]) *node[T] {
+	if len(entries) == 0 {
+		return nil
+	}
+
+	mid := len(entries) / 2
+	n := &node[T]{
+		entry:  entries[mid],
+		maxEnd: entries[mid].Interval.End,
+		left:   buildNode(entries[:mid]),
+		right:  buildNode(entries[mid+1:]),
+	}
+	if n.left != nil {
+		n.maxEnd = max(n.maxEnd, n.left.maxEnd)
+	}
+	if n.right != nil {
+		n.maxEnd = max(n.maxEnd, n.right.maxEnd)
+	}
+
+	return n
+}
+
+func (t *Tree[T]) Len() int {
+	return t.size
+}
+
+func (t *Tree[T]) Stab(x int) []Entry[T] {
+	return t.Query(Interval{x, x + 1})
+}
+
+func (t *Tree[T]) Query(r Interval) []Entry[T] {
+	var found []Entry[T]
+	t.root.query(r, &found)
+	return found
+}
+
+func (n *node[T]) query(r Interval, found *[]Entry[T]) {
+	if n == nil || n.maxEnd <= r.Start {
+		return
+	}
+
+	n.left.query(r, found)
+	if n.entry.Interval.Start >= r.End {
+		return
+	}
+	if n.entry.Interval.Overlaps(r) {
+		*found = append(*found, n.entry)
+	}
+	n.right.query(r, found)
+}
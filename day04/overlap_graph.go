@@ -0,0 +1,186 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"sort"
+
+	"github.com/davidaf3/advent-of-code-2022/day04/interval"
+)
+
+type Elf struct {
+	id         int
+	pair       int
+	assignment *Assignment
+}
+
+func (e *Elf) String() string {
+	return fmt.Sprintf("elf %d (pair %d)", e.id+1, e.pair+1)
+}
+
+type OverlapGraph struct {
+	elves []*Elf
+	edges [][]int
+}
+
+func newOverlapGraph(pairs []*Pair) *OverlapGraph {
+	var elves []*Elf
+	for i, pair := range pairs {
+		elves = append(elves,
+			&Elf{len(elves), i, pair.first},
+			&Elf{len(elves) + 1, i, pair.second},
+		)
+	}
+
+	byStart := make([]*Elf, len(elves))
+	copy(byStart, elves)
+	sort.SliceStable(byStart, func(i, j int) bool {
+		return byStart[i].assignment.Start < byStart[j].assignment.Start
+	})
+
+	edges := make([][]int, len(elves))
+	for i, elf := range byStart {
+		for _, other := range byStart[i+1:] {
+			if other.assignment.Start >= elf.assignment.End {
+				break
+			}
+			edges[elf.id] = append(edges[elf.id], other.id)
+			edges[other.id] = append(edges[other.id], elf.id)
+		}
+	}
+
+	return &OverlapGraph{elves, edges}
+}
+
+func (g *OverlapGraph) components() [][]*Elf {
+	visited := make([]bool, len(g.elves))
+	var components [][]*Elf
+
+	for _, elf := range g.elves {
+		if visited[elf.id] {
+			continue
+		}
+
+		visited[elf.id] = true
+		component := []*Elf{elf}
+		for i := 0; i < len(component); i++ {
+			for _, neighbour := range g.edges[component[i].id] {
+				if !visited[neighbour] {
+					visited[neighbour] = true
+					component = append(component, g.elves[neighbour])
+				}
+			}
+		}
+		components = append(components, component)
+	}
+
+	return components
+}
+
+func (g *OverlapGraph) maxClique() (int, []*Elf) {
+	var intervals []interval.Interval
+	var entries []interval.Entry[*Elf]
+	for _, elf := range g.elves {
+		intervals = append(intervals, elf.assignment.Interval)
+		entries = append(entries, interval.Entry[*Elf]{Interval: elf.assignment.Interval, Value: elf})
+	}
+
+	section, _ := interval.MostOverlapped(intervals)
+	var clique []*Elf
+	for _, entry := range interval.NewTree(entries).Stab(section) {
+		clique = append(clique, entry.Value)
+	}
+
+	return section, clique
+}
+
+type Reassignment struct {
+	elf        *Elf
+	assignment interval.Interval
+}
+
+func (g *OverlapGraph) schedule() []*Reassignment {
+	byStart := make([]*Elf, len(g.elves))
+	copy(byStart, g.elves)
+	sort.SliceStable(byStart, func(i, j int) bool {
+		return byStart[i].assignment.Start < byStart[j].assignment.Start
+	})
+
+	kept := make(map[int]interval.Interval)
+	covered := 0
+	for i := 0; i < len(byStart); {
+		if byStart[i].assignment.End <= covered {
+			i++
+			continue
+		}
+
+		start := max(covered, byStart[i].assignment.Start)
+		var best *Elf
+		for ; i < len(byStart) && byStart[i].assignment.Start <= start; i++ {
+			if best == nil || byStart[i].assignment.End > best.assignment.End {
+				best = byStart[i]
+			}
+		}
+		if best == nil || best.assignment.End <= start {
+			continue
+		}
+
+		kept[best.id] = interval.HalfOpen(start, best.assignment.End)
+		covered = best.assignment.End
+	}
+
+	var reassignments []*Reassignment
+	for _, elf := range g.elves {
+		reassignments = append(reassignments, &Reassignment{elf, kept[elf.id]})
+	}
+
+	return reassignments
+}
+
+func (g *OverlapGraph) writeReport(w io.Writer) {
+	components := g.components()
+	fmt.Fprintf(w, "components: %d\n", len(components))
+	for i, component := range components {
+		var intervals []interval.Interval
+		for _, elf := range component {
+			intervals = append(intervals, elf.assignment.Interval)
+		}
+		fmt.Fprintf(w, "component %d: %d elves, sections %v\n",
+			i+1, len(component), interval.Merge(intervals))
+	}
+
+	section, clique := g.maxClique()
+	fmt.Fprintf(w, "max clique: %d elves at section %d\n", len(clique), section)
+
+	fmt.Fprintln(w, "schedule:")
+	for _, r := range g.schedule() {
+		if r.assignment.Empty() {
+			fmt.Fprintf(w, "%v: %v -> released\n", r.elf, r.elf.assignment.Interval)
+		} else {
+			fmt.Fprintf(w, "%v: %v -> %v\n", r.elf, r.elf.assignment.Interval, r.assignment)
+		}
+	}
+}
+
+func (g *OverlapGraph) writeDot(w io.Writer) {
+	fmt.Fprintln(w, "graph overlaps {")
+	for _, elf := range g.elves {
+		fmt.Fprintf(w, "\te%d [label=\"%d (pair %d)\\n%v\"];\n",
+			elf.id+1, elf.id+1, elf.pair+1, elf.assignment.Interval)
+	}
+	for id, neighbours := range g.edges {
+		for _, neighbour := range neighbours {
+			if id < neighbour {
+				fmt.Fprintf(w, "\te%d -- e%d;\n", id+1, neighbour+1)
+			}
+		}
+	}
+	fmt.Fprintln(w, "}")
+}
+
+func max(a, b int) int {
+	if a > b {
+		return a
+	}
+	return b
+}
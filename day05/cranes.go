@@ -0,0 +1,126 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+type Stats struct {
+	lifts int
+	cost  int
+}
+
+func (s *Stats) add(other Stats) {
+	s.lifts += other.lifts
+	s.cost += other.cost
+}
+
+type Crane interface {
+	move(stacks []*Stack[byte], c *Command) (Stats, error)
+}
+
+type CraneConfig struct {
+	capacity  int
+	maxHeight int
+	liftCost  int
+	crateCost int
+}
+
+var cranes = map[string]func(config CraneConfig) Crane{
+	"9000": func(config CraneConfig) Crane {
+		return &CapacityCrane{1}
+	},
+	"9001": func(config CraneConfig) Crane {
+		return &CapacityCrane{0}
+	},
+	"capacity": func(config CraneConfig) Crane {
+		return &CapacityCrane{config.capacity}
+	},
+	"costed": func(config CraneConfig) Crane {
+		return &CostedCrane{config.liftCost, config.crateCost}
+	},
+	"limited": func(config CraneConfig) Crane {
+		return &HeightLimitedCrane{&CapacityCrane{0}, config.maxHeight}
+	},
+}
+
+func craneNames() []string {
+	var names []string
+	for name := range cranes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func newCrane(name string, config CraneConfig) (Crane, error) {
+	factory, ok := cranes[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown crane %q (available: %s)",
+			name, strings.Join(craneNames(), ", "))
+	}
+	return factory(config), nil
+}
+
+func liftCrates(stacks []*Stack[byte], from, to, n int) {
+	crates := make([]byte, n)
+	for i := n - 1; i >= 0; i-- {
+		crates[i], _ = stacks[from].pop()
+	}
+	for _, crate := range crates {
+		stacks[to].push(crate)
+	}
+}
+
+type CapacityCrane struct {
+	capacity int
+}
+
+func (cr *CapacityCrane) move(stacks []*Stack[byte], c *Command) (Stats, error) {
+	if err := c.validate(stacks); err != nil {
+		return Stats{}, err
+	}
+
+	var stats Stats
+	for remaining := c.moves; remaining > 0; {
+		n := remaining
+		if cr.capacity > 0 && n > cr.capacity {
+			n = cr.capacity
+		}
+		liftCrates(stacks, c.from, c.to, n)
+		remaining -= n
+		stats.add(Stats{1, 1})
+	}
+	return stats, nil
+}
+
+type CostedCrane struct {
+	liftCost  int
+	crateCost int
+}
+
+func (cr *CostedCrane) move(stacks []*Stack[byte], c *Command) (Stats, error) {
+	if err := c.validate(stacks); err != nil {
+		return Stats{}, err
+	}
+
+	liftCrates(stacks, c.from, c.to, c.moves)
+	return Stats{1, cr.liftCost + cr.crateCost*c.moves}, nil
+}
+
+type HeightLimitedCrane struct {
+	Crane
+	maxHeight int
+}
+
+func (cr *HeightLimitedCrane) move(stacks []*Stack[byte], c *Command) (Stats, error) {
+	if err := c.validate(stacks); err != nil {
+		return Stats{}, err
+	}
+	if height := stacks[c.to].len() + c.moves; cr.maxHeight > 0 && height > cr.maxHeight {
+		return Stats{}, fmt.Errorf("line %d: %v: stack %d would reach height %d (limit %d)",
+			c.line, c, c.to+1, height, cr.maxHeight)
+	}
+	return cr.Crane.move(stacks, c)
+}
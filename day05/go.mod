@@ -0,0 +1,3 @@
+module github.com/davidaf3/advent-of-code-2022/day05
+
+go 1.19
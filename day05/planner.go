@@ -0,0 +1,140 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/davidaf3/advent-of-code-2022/day05/astar"
+)
+
+type StacksState struct {
+	stacks    []*Stack[byte]
+	commands  []*Command
+	heuristic int
+	hash      string
+}
+
+func (s *StacksState) GetCost() int {
+	return len(s.commands)
+}
+
+func (s *StacksState) GetHeuristicValue() int {
+	return s.heuristic
+}
+
+func (s *StacksState) GetHash() string {
+	return s.hash
+}
+
+func getStacksHash(stacks []*Stack[byte]) string {
+	parts := make([]string, len(stacks))
+	for i, stack := range stacks {
+		parts[i] = string(stack.items)
+	}
+	return strings.Join(parts, "|")
+}
+
+func newStacksState(stacks []*Stack[byte], commands []*Command,
+	h func(*StacksState, astar.Problem[*StacksState]) int,
+	problem *StacksProblem) *StacksState {
+	state := &StacksState{
+		stacks:   stacks,
+		commands: commands,
+		hash:     getStacksHash(stacks),
+	}
+
+	state.heuristic = h(state, problem)
+	return state
+}
+
+type StacksProblem struct {
+	initial []*Stack[byte]
+	target  []*Stack[byte]
+	crane   Crane
+}
+
+func newStacksProblem(initial, target []*Stack[byte], crane Crane) (*StacksProblem, error) {
+	if len(initial) != len(target) {
+		return nil, fmt.Errorf("target has %d stacks, expected %d", len(target), len(initial))
+	}
+	if getSortedCrates(initial) != getSortedCrates(target) {
+		return nil, fmt.Errorf("target crates do not match the initial crates")
+	}
+	return &StacksProblem{initial, target, crane}, nil
+}
+
+func getSortedCrates(stacks []*Stack[byte]) string {
+	var crates []byte
+	for _, stack := range stacks {
+		crates = append(crates, stack.items...)
+	}
+	sort.Slice(crates, func(i, j int) bool {
+		return crates[i] < crates[j]
+	})
+	return string(crates)
+}
+
+func (p *StacksProblem) GetInitialState(
+	h func(*StacksState, astar.Problem[*StacksState]) int) *StacksState {
+	return newStacksState(cloneStacks(p.initial), nil, h, p)
+}
+
+func (p *StacksProblem) IsFinal(s *StacksState) bool {
+	return s.hash == getStacksHash(p.target)
+}
+
+func (p *StacksProblem) Expand(s *StacksState,
+	h func(*StacksState, astar.Problem[*StacksState]) int) []*StacksState {
+	var children []*StacksState
+	for from, stack := range s.stacks {
+		for to := range s.stacks {
+			if from == to {
+				continue
+			}
+
+			for moves := 1; moves <= stack.len(); moves++ {
+				command := &Command{moves, from, to, len(s.commands) + 1}
+				stacks := cloneStacks(s.stacks)
+				if _, err := p.crane.move(stacks, command); err != nil {
+					continue
+				}
+
+				commands := make([]*Command, len(s.commands), len(s.commands)+1)
+				copy(commands, s.commands)
+				children = append(children,
+					newStacksState(stacks, append(commands, command), h, p))
+			}
+		}
+	}
+
+	return children
+}
+
+func H(s *StacksState, problem astar.Problem[*StacksState]) int {
+	p := problem.(*StacksProblem)
+	wrong := 0
+	for i, stack := range s.stacks {
+		if string(stack.items) != string(p.target[i].items) {
+			wrong += 1
+		}
+	}
+	return (wrong + 1) / 2
+}
+
+func planCommands(initial, target []*Stack[byte], crane Crane,
+	maxStates int) ([]*Command, error) {
+	problem, err := newStacksProblem(initial, target, crane)
+	if err != nil {
+		return nil, err
+	}
+
+	result, complete := astar.BoundedAStar[*StacksState](problem, H, maxStates)
+	if !complete {
+		return nil, fmt.Errorf("no plan within %d states", maxStates)
+	}
+	if result == nil {
+		return nil, fmt.Errorf("target layout is unreachable with this crane")
+	}
+	return result.(*StacksState).commands, nil
+}
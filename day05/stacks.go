@@ -2,6 +2,8 @@ package main
 
 import (
 	"bufio"
+	"errors"
+	"flag"
 	"fmt"
 	"log"
 	"os"
@@ -10,6 +12,8 @@ import (
 	"strings"
 )
 
+var errEmptyStack = errors.New("empty stack")
+
 type Stack[T any] struct {
 	items []T
 }
@@ -23,63 +27,189 @@ func (s *Stack[T]) push(item T) {
 	s.items = append(s.items, item)
 }
 
-func (s *Stack[T]) pop() T {
+func (s *Stack[T]) pop() (T, error) {
+	var last T
+	if len(s.items) == 0 {
+		return last, errEmptyStack
+	}
+
 	lastIdx := len(s.items) - 1
-	last := s.items[lastIdx]
+	last = s.items[lastIdx]
 	s.items = s.items[:lastIdx]
-	return last
+	return last, nil
+}
+
+func (s *Stack[T]) peek() (T, bool) {
+	var last T
+	if len(s.items) == 0 {
+		return last, false
+	}
+	return s.items[len(s.items)-1], true
 }
 
-func (s *Stack[T]) peek() T {
-	return s.items[len(s.items)-1]
+func (s *Stack[T]) len() int {
+	return len(s.items)
+}
+
+func (s *Stack[T]) clone() *Stack[T] {
+	items := make([]T, len(s.items))
+	copy(items, s.items)
+	return &Stack[T]{items}
+}
+
+func cloneStacks(stacks []*Stack[byte]) []*Stack[byte] {
+	cloned := make([]*Stack[byte], len(stacks))
+	for i, stack := range stacks {
+		cloned[i] = stack.clone()
+	}
+	return cloned
 }
 
+var commandRegex = regexp.MustCompile("^move ([0-9]+) from ([0-9]+) to ([0-9]+)$")
+
 type Command struct {
 	moves int
 	from  int
 	to    int
+	line  int
 }
 
-func newCommandFromText(moves, from, to string) *Command {
-	movesN, err := strconv.Atoi(moves)
-	errorHandler(err)
-	fromN, err := strconv.Atoi(from)
-	errorHandler(err)
-	toN, err := strconv.Atoi(to)
-	errorHandler(err)
-	return &Command{movesN, fromN - 1, toN - 1}
+func newCommandFromText(text string, line int) (*Command, error) {
+	args := commandRegex.FindStringSubmatch(text)
+	if args == nil {
+		return nil, fmt.Errorf("line %d: invalid command %q", line, text)
+	}
+
+	movesN, err := strconv.Atoi(args[1])
+	if err != nil {
+		return nil, fmt.Errorf("line %d: %w", line, err)
+	}
+	fromN, err := strconv.Atoi(args[2])
+	if err != nil {
+		return nil, fmt.Errorf("line %d: %w", line, err)
+	}
+	toN, err := strconv.Atoi(args[3])
+	if err != nil {
+		return nil, fmt.Errorf("line %d: %w", line, err)
+	}
+
+	return &Command{movesN, fromN - 1, toN - 1, line}, nil
+}
+
+func (c *Command) String() string {
+	return fmt.Sprintf("move %d from %d to %d", c.moves, c.from+1, c.to+1)
+}
+
+func (c *Command) validate(stacks []*Stack[byte]) error {
+	if c.from < 0 || c.from >= len(stacks) {
+		return fmt.Errorf("line %d: %v: no stack %d", c.line, c, c.from+1)
+	}
+	if c.to < 0 || c.to >= len(stacks) {
+		return fmt.Errorf("line %d: %v: no stack %d", c.line, c, c.to+1)
+	}
+	if c.moves > stacks[c.from].len() {
+		return fmt.Errorf("line %d: %v: stack %d has only %d crates",
+			c.line, c, c.from+1, stacks[c.from].len())
+	}
+	return nil
+}
+
+type Simulator struct {
+	stacks   []*Stack[byte]
+	crane    Crane
+	commands []*Command
+	history  [][]*Stack[byte]
+	stats    []Stats
+}
+
+func newSimulator(stacks []*Stack[byte], crane Crane, commands []*Command) *Simulator {
+	return &Simulator{stacks: stacks, crane: crane, commands: commands}
 }
 
-type CrateMover9000Command struct {
-	Command
+func (s *Simulator) position() int {
+	return len(s.history)
 }
 
-func (c *CrateMover9000Command) run(stacks []*Stack[byte]) {
-	for i := 0; i < c.moves; i++ {
-		crate := stacks[c.from].pop()
-		stacks[c.to].push(crate)
+func (s *Simulator) step() (bool, error) {
+	if s.position() == len(s.commands) {
+		return false, nil
 	}
+
+	snapshot := cloneStacks(s.stacks)
+	stats, err := s.crane.move(s.stacks, s.commands[s.position()])
+	if err != nil {
+		s.stacks = snapshot
+		return false, err
+	}
+	s.history = append(s.history, snapshot)
+	s.stats = append(s.stats, stats)
+	return true, nil
 }
 
-type CrateMover9001Command struct {
-	Command
+func (s *Simulator) undo() bool {
+	if s.position() == 0 {
+		return false
+	}
+
+	last := len(s.history) - 1
+	s.stacks = s.history[last]
+	s.history = s.history[:last]
+	s.stats = s.stats[:last]
+	return true
 }
 
-func (c *CrateMover9001Command) run(stacks []*Stack[byte]) {
-	var crates []byte
-	for i := 0; i < c.moves; i++ {
-		crates = append(crates, stacks[c.from].pop())
+func (s *Simulator) seek(position int) error {
+	if position < 0 || position > len(s.commands) {
+		return fmt.Errorf("step %d out of range [0, %d]", position, len(s.commands))
+	}
+
+	for s.position() > position {
+		s.undo()
 	}
-	for i := len(crates) - 1; i >= 0; i-- {
-		stacks[c.to].push(crates[i])
+	for s.position() < position {
+		if _, err := s.step(); err != nil {
+			return err
+		}
 	}
+	return nil
+}
+
+func (s *Simulator) run() error {
+	return s.seek(len(s.commands))
 }
 
-func getStacksFromText(lines []string) []*Stack[byte] {
+func (s *Simulator) totalStats() Stats {
+	var total Stats
+	for _, stats := range s.stats {
+		total.add(stats)
+	}
+	return total
+}
+
+func getTops(stacks []*Stack[byte]) string {
+	var tops []byte
+	for _, stack := range stacks {
+		if top, ok := stack.peek(); ok {
+			tops = append(tops, top)
+		}
+	}
+	return string(tops)
+}
+
+func getStacksFromText(lines []string) ([]*Stack[byte], error) {
+	if len(lines) == 0 {
+		return nil, errors.New("missing stacks diagram")
+	}
+
 	lastLine := len(lines) - 1
-	stackIndexes := strings.Split(strings.Trim(lines[lastLine], " "), " ")
+	stackIndexes := strings.Fields(lines[lastLine])
+	if len(stackIndexes) == 0 {
+		return nil, fmt.Errorf("line %d: missing stack indexes", lastLine+1)
+	}
 	nStacks, err := strconv.Atoi(stackIndexes[len(stackIndexes)-1])
-	errorHandler(err)
+	if err != nil {
+		return nil, fmt.Errorf("line %d: %w", lastLine+1, err)
+	}
 
 	var stacks []*Stack[byte]
 	for i := 0; i < nStacks; i++ {
@@ -87,22 +217,99 @@ func getStacksFromText(lines []string) []*Stack[byte] {
 	}
 
 	for i := lastLine - 1; i >= 0; i-- {
-		fillStacks(lines[i], stacks)
+		if err := fillStacks(lines[i], stacks); err != nil {
+			return nil, fmt.Errorf("line %d: %w", i+1, err)
+		}
 	}
 
-	return stacks
+	return stacks, nil
 }
 
-func fillStacks(line string, stacks []*Stack[byte]) {
+func fillStacks(line string, stacks []*Stack[byte]) error {
 	for stack := 0; stack*4 < len(line); stack++ {
 		linePos := stack * 4
-		if line[linePos] == '[' {
-			stacks[stack].push(line[linePos+1])
+		if line[linePos] != '[' {
+			continue
+		}
+		if stack >= len(stacks) {
+			return fmt.Errorf("crate in column %d beyond stack %d", linePos+1, len(stacks))
+		}
+		if linePos+2 >= len(line) || line[linePos+2] != ']' {
+			return fmt.Errorf("unterminated crate in column %d", linePos+1)
+		}
+		stacks[stack].push(line[linePos+1])
+	}
+	return nil
+}
+
+func getTextFromStacks(stacks []*Stack[byte]) []string {
+	height := 0
+	for _, stack := range stacks {
+		if stack.len() > height {
+			height = stack.len()
 		}
 	}
+
+	var lines []string
+	for level := height - 1; level >= 0; level-- {
+		cells := make([]string, len(stacks))
+		for i, stack := range stacks {
+			if level < stack.len() {
+				cells[i] = fmt.Sprintf("[%c]", stack.items[level])
+			} else {
+				cells[i] = "   "
+			}
+		}
+		lines = append(lines, strings.Join(cells, " "))
+	}
+
+	indexes := make([]string, len(stacks))
+	for i := range stacks {
+		indexes[i] = fmt.Sprintf(" %d ", i+1)
+	}
+
+	return append(lines, strings.Join(indexes, " "))
+}
+
+func readStacksFile(path string) ([]*Stack[byte], error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var lines []string
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() && scanner.Text() != "" {
+		lines = append(lines, scanner.Text())
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	stacks, err := getStacksFromText(lines)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return stacks, nil
 }
 
 func main() {
+	craneList := flag.String("crane", "9000,9001",
+		"comma-separated cranes to run ("+strings.Join(craneNames(), ", ")+")")
+	capacity := flag.Int("capacity", 3, "crates per lift for the capacity crane")
+	maxHeight := flag.Int("max-height", 0, "maximum stack height for the limited crane")
+	liftCost := flag.Int("lift-cost", 1, "cost per lift for the costed crane")
+	crateCost := flag.Int("crate-cost", 1, "cost per crate for the costed crane")
+	report := flag.Bool("report", false, "print lifts and cost for each crane")
+	steps := flag.Int("steps", -1, "number of commands to apply (default all)")
+	diagram := flag.Bool("diagram", false, "print the resulting stacks diagrams")
+	target := flag.String("target", "", "diagram file with the layout to plan commands for")
+	maxStates := flag.Int("max-states", 200000, "states the planner may generate (0 for no limit)")
+	flag.Parse()
+
+	config := CraneConfig{*capacity, *maxHeight, *liftCost, *crateCost}
+
 	f, err := os.Open("input.txt")
 	errorHandler(err)
 	defer f.Close()
@@ -113,26 +320,55 @@ func main() {
 		stackLines = append(stackLines, scanner.Text())
 	}
 
-	firstStacks := getStacksFromText(stackLines)
-	secondStacks := getStacksFromText(stackLines)
-	commandRegex, err := regexp.Compile("move ([0-9]+) from ([0-9]+) to ([0-9]+)")
+	stacks, err := getStacksFromText(stackLines)
 	errorHandler(err)
-	for scanner.Scan() {
-		args := commandRegex.FindStringSubmatch(scanner.Text())
-		command := newCommandFromText(args[1], args[2], args[3])
-		(&CrateMover9000Command{*command}).run(firstStacks)
-		(&CrateMover9001Command{*command}).run(secondStacks)
+
+	var commands []*Command
+	for line := len(stackLines) + 2; scanner.Scan(); line++ {
+		command, err := newCommandFromText(scanner.Text(), line)
+		errorHandler(err)
+		commands = append(commands, command)
 	}
+	errorHandler(scanner.Err())
 
-	var firstTop []byte
-	var secondTop []byte
-	for i := range firstStacks {
-		firstTop = append(firstTop, firstStacks[i].peek())
-		secondTop = append(secondTop, secondStacks[i].peek())
+	if *target != "" {
+		targetStacks, err := readStacksFile(*target)
+		errorHandler(err)
+		crane, err := newCrane(strings.Split(*craneList, ",")[0], config)
+		errorHandler(err)
+		plan, err := planCommands(stacks, targetStacks, crane, *maxStates)
+		errorHandler(err)
+
+		fmt.Println(strings.Join(stackLines, "\n"))
+		fmt.Println()
+		for _, command := range plan {
+			fmt.Println(command)
+		}
+		return
 	}
 
-	fmt.Println(string(firstTop))
-	fmt.Println(string(secondTop))
+	for _, name := range strings.Split(*craneList, ",") {
+		crane, err := newCrane(name, config)
+		errorHandler(err)
+
+		simulator := newSimulator(cloneStacks(stacks), crane, commands)
+		if *steps >= 0 {
+			errorHandler(simulator.seek(*steps))
+		} else {
+			errorHandler(simulator.run())
+		}
+
+		if *report {
+			stats := simulator.totalStats()
+			fmt.Printf("%s: %s lifts=%d cost=%d\n",
+				name, getTops(simulator.stacks), stats.lifts, stats.cost)
+		} else {
+			fmt.Println(getTops(simulator.stacks))
+		}
+		if *diagram {
+			fmt.Println(strings.Join(getTextFromStacks(simulator.stacks), "\n"))
+		}
+	}
 }
 
 func errorHandler(err error) {
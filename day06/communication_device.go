@@ -2,49 +2,168 @@ package main
 
 import (
 	"bufio"
+	"flag"
 	"fmt"
+	"io"
 	"log"
 	"os"
+	"strconv"
+	"strings"
 )
 
-func getDistinctPosition(datastream []byte, nDistinct int) int {
-	for i := 0; i < len(datastream); {
-		distinct, skip := isDistinct(datastream[i : i+nDistinct])
-		if distinct {
-			return i + nDistinct
+type MarkerDetector struct {
+	size      int
+	window    []byte
+	counts    [256]int
+	repeated  int
+	processed int
+}
+
+func newMarkerDetector(size int) *MarkerDetector {
+	return &MarkerDetector{size: size, window: make([]byte, size)}
+}
+
+func (d *MarkerDetector) push(datum byte) bool {
+	slot := d.processed % d.size
+	if d.processed >= d.size {
+		old := d.window[slot]
+		d.counts[old] -= 1
+		if d.counts[old] == 1 {
+			d.repeated -= 1
 		}
-		i += skip
 	}
 
-	return -1
+	d.window[slot] = datum
+	d.counts[datum] += 1
+	if d.counts[datum] == 2 {
+		d.repeated += 1
+	}
+	d.processed += 1
+
+	return d.processed >= d.size && d.repeated == 0
+}
+
+type DatastreamReader struct {
+	reader      *bufio.Reader
+	keepNewline bool
+}
+
+func newDatastreamReader(r io.Reader, keepNewline bool) *DatastreamReader {
+	return &DatastreamReader{bufio.NewReaderSize(r, 1<<16), keepNewline}
+}
+
+func (dr *DatastreamReader) ReadByte() (byte, error) {
+	datum, err := dr.reader.ReadByte()
+	if err != nil || dr.keepNewline || (datum != '\n' && datum != '\r') {
+		return datum, err
+	}
+
+	next, _ := dr.reader.Peek(1)
+	if datum == '\n' && len(next) == 0 {
+		return 0, io.EOF
+	}
+	if datum == '\r' && len(next) == 1 && next[0] == '\n' {
+		if rest, _ := dr.reader.Peek(2); len(rest) == 1 {
+			dr.reader.Discard(1)
+			return 0, io.EOF
+		}
+	}
+	return datum, nil
 }
 
-func isDistinct(data []byte) (bool, int) {
-	visited := make([]int, 'z'-'a'+1)
+func scanMarkers(reader io.ByteReader, sizes []int, onMarker func(size, offset int) bool) error {
+	detectors := make([]*MarkerDetector, len(sizes))
+	for i, size := range sizes {
+		detectors[i] = newMarkerDetector(size)
+	}
+
+	for {
+		datum, err := reader.ReadByte()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
 
-	for i, datum := range data {
-		if visited[datum-'a'] != 0 {
-			return false, visited[datum-'a']
+		for _, detector := range detectors {
+			if detector.push(datum) && !onMarker(detector.size, detector.processed) {
+				return nil
+			}
 		}
-		visited[datum-'a'] = i + 1
 	}
+}
 
-	return true, 0
+func parseSizes(text string) ([]int, error) {
+	var sizes []int
+	for _, field := range strings.Split(text, ",") {
+		size, err := strconv.Atoi(field)
+		if err != nil {
+			return nil, err
+		}
+		if size < 1 {
+			return nil, fmt.Errorf("invalid window size %d", size)
+		}
+		sizes = append(sizes, size)
+	}
+	return sizes, nil
 }
 
 func main() {
-	f, err := os.Open("input.txt")
+	input := flag.String("input", "input.txt", "datastream file, or - for stdin")
+	sizesText := flag.String("sizes", "4,14", "comma-separated marker window sizes")
+	all := flag.Bool("all", false, "report every marker offset instead of the first one per size")
+	frames := flag.Bool("frames", false, "split the stream into frames between message markers")
+	format := flag.String("format", "table", "frame output format: table or json")
+	framesDir := flag.String("frames-dir", "", "directory to write each frame to its own file")
+	keepNewline := flag.Bool("keep-newline", false, "keep a trailing \\n or \\r\\n as stream data instead of stripping it")
+	flag.Parse()
+
+	sizes, err := parseSizes(*sizesText)
 	errorHandler(err)
-	defer f.Close()
 
-	scanner := bufio.NewScanner(f)
-	scanner.Scan()
-	datastream := scanner.Bytes()
-	sop := getDistinctPosition(datastream, 4)
-	som := getDistinctPosition(datastream[sop-4:], 14) + sop - 4
+	var r io.Reader = os.Stdin
+	if *input != "-" {
+		f, err := os.Open(*input)
+		errorHandler(err)
+		defer f.Close()
+		r = f
+	}
+	reader := newDatastreamReader(r, *keepNewline)
+
+	if *frames {
+		if len(sizes) != 2 {
+			log.Fatal("framing needs exactly two sizes: packet and message")
+		}
+		printer, err := newFramePrinter(os.Stdout, *format)
+		errorHandler(err)
+		errorHandler(newFramer(sizes[0], sizes[1], *framesDir, printer).run(reader))
+		return
+	}
+
+	if *all {
+		errorHandler(scanMarkers(reader, sizes, func(size, offset int) bool {
+			fmt.Println(size, offset)
+			return true
+		}))
+		return
+	}
 
-	fmt.Println(sop)
-	fmt.Println(som)
+	first := make(map[int]int)
+	errorHandler(scanMarkers(reader, sizes, func(size, offset int) bool {
+		if _, found := first[size]; !found {
+			first[size] = offset
+		}
+		return len(first) < len(sizes)
+	}))
+
+	for _, size := range sizes {
+		if offset, found := first[size]; found {
+			fmt.Println(offset)
+		} else {
+			fmt.Println(-1)
+		}
+	}
 }
 
 func errorHandler(err error) {
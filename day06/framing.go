@@ -0,0 +1,158 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"hash"
+	"hash/crc32"
+	"io"
+	"os"
+	"path/filepath"
+)
+
+type Frame struct {
+	Index    int    `json:"index"`
+	Offset   int    `json:"offset"`
+	Length   int    `json:"length"`
+	Packets  int    `json:"packets"`
+	Checksum uint32 `json:"crc32"`
+	Complete bool   `json:"complete"`
+}
+
+type Framer struct {
+	packetDetector  *MarkerDetector
+	messageDetector *MarkerDetector
+	processed       int
+	dir             string
+	onFrame         func(*Frame) error
+	current         *Frame
+	checksum        hash.Hash32
+	file            *os.File
+	writer          *bufio.Writer
+}
+
+func newFramer(packetSize, messageSize int, dir string, onFrame func(*Frame) error) *Framer {
+	return &Framer{
+		packetDetector:  newMarkerDetector(packetSize),
+		messageDetector: newMarkerDetector(messageSize),
+		dir:             dir,
+		onFrame:         onFrame,
+		checksum:        crc32.NewIEEE(),
+	}
+}
+
+func (f *Framer) push(datum byte) error {
+	f.processed += 1
+	if f.current != nil {
+		f.current.Length += 1
+		f.checksum.Write([]byte{datum})
+		if f.writer != nil {
+			if err := f.writer.WriteByte(datum); err != nil {
+				return err
+			}
+		}
+	}
+
+	if f.packetDetector.push(datum) && f.current != nil {
+		f.current.Packets += 1
+	}
+
+	if f.messageDetector.push(datum) {
+		index := 0
+		if f.current != nil {
+			index = f.current.Index + 1
+		}
+		if err := f.close(true); err != nil {
+			return err
+		}
+		f.messageDetector = newMarkerDetector(f.messageDetector.size)
+		return f.open(index, f.processed)
+	}
+
+	return nil
+}
+
+func (f *Framer) open(index, offset int) error {
+	f.current = &Frame{Index: index, Offset: offset}
+	f.checksum.Reset()
+	if f.dir == "" {
+		return nil
+	}
+
+	file, err := os.Create(filepath.Join(f.dir, fmt.Sprintf("frame-%06d.bin", index)))
+	if err != nil {
+		return err
+	}
+	f.file = file
+	f.writer = bufio.NewWriter(file)
+	return nil
+}
+
+func (f *Framer) close(complete bool) error {
+	if f.current == nil {
+		return nil
+	}
+
+	frame := f.current
+	frame.Checksum = f.checksum.Sum32()
+	frame.Complete = complete
+	f.current = nil
+
+	if f.file != nil {
+		file, writer := f.file, f.writer
+		f.file, f.writer = nil, nil
+		if err := writer.Flush(); err != nil {
+			return err
+		}
+		if err := file.Close(); err != nil {
+			return err
+		}
+		if frame.Length == 0 {
+			if err := os.Remove(file.Name()); err != nil {
+				return err
+			}
+		}
+	}
+
+	if frame.Length == 0 {
+		return nil
+	}
+	return f.onFrame(frame)
+}
+
+func (f *Framer) run(reader io.ByteReader) error {
+	for {
+		datum, err := reader.ReadByte()
+		if err == io.EOF {
+			return f.close(false)
+		}
+		if err != nil {
+			return err
+		}
+
+		if err := f.push(datum); err != nil {
+			return err
+		}
+	}
+}
+
+func newFramePrinter(w io.Writer, format string) (func(*Frame) error, error) {
+	switch format {
+	case "table":
+		fmt.Fprintf(w, "%8s %12s %10s %8s %10s %9s\n",
+			"index", "offset", "length", "packets", "crc32", "complete")
+		return func(frame *Frame) error {
+			_, err := fmt.Fprintf(w, "%8d %12d %10d %8d %08x   %9t\n",
+				frame.Index, frame.Offset, frame.Length, frame.Packets, frame.Checksum, frame.Complete)
+			return err
+		}, nil
+	case "json":
+		encoder := json.NewEncoder(w)
+		return func(frame *Frame) error {
+			return encoder.Encode(frame)
+		}, nil
+	default:
+		return nil, fmt.Errorf("unknown frame format %q", format)
+	}
+}
@@ -0,0 +1,201 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"html"
+	"io"
+	"math"
+	"sort"
+	"time"
+)
+
+type JSONNode struct {
+	Name     string      `json:"name"`
+	Type     string      `json:"type"`
+	Size     int64       `json:"size"`
+	Children []*JSONNode `json:"children,omitempty"`
+}
+
+type JSONVisitor struct {
+	result *JSONNode
+}
+
+func (jv *JSONVisitor) visitFile(file *File) {
+	jv.result = &JSONNode{Name: file.name, Type: "file", Size: file.size}
+}
+
+func (jv *JSONVisitor) visitDirectory(directory *Directory) {
+	node := &JSONNode{Name: directory.name, Type: "dir", Size: directory.size}
+	for _, child := range directory.sortedChildren() {
+		child.accept(jv)
+		node.Children = append(node.Children, jv.result)
+	}
+	jv.result = node
+}
+
+func (jv *JSONVisitor) write(w io.Writer) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(jv.result)
+}
+
+type NcduVisitor struct {
+	result any
+}
+
+func (nv *NcduVisitor) visitFile(file *File) {
+	nv.result = map[string]any{"name": file.name, "asize": file.size, "dsize": file.size}
+}
+
+func (nv *NcduVisitor) visitDirectory(directory *Directory) {
+	entries := []any{map[string]any{"name": directory.name}}
+	for _, child := range directory.sortedChildren() {
+		child.accept(nv)
+		entries = append(entries, nv.result)
+	}
+	nv.result = entries
+}
+
+func (nv *NcduVisitor) write(w io.Writer) error {
+	header := map[string]any{
+		"progname":  "aoc-day07",
+		"progver":   "1.0",
+		"timestamp": time.Now().Unix(),
+	}
+	return json.NewEncoder(w).Encode([]any{1, 0, header, nv.result})
+}
+
+type Rect struct {
+	x, y, w, h float64
+}
+
+type TreemapVisitor struct {
+	out     io.Writer
+	rect    Rect
+	depth   int
+	padding float64
+}
+
+func newTreemapVisitor(out io.Writer, width, height float64) *TreemapVisitor {
+	return &TreemapVisitor{out: out, rect: Rect{0, 0, width, height}, padding: 2}
+}
+
+func (tv *TreemapVisitor) visitFile(file *File) {
+	tv.drawRect(file.getPath(), file.size, fmt.Sprintf("hsl(%d, 60%%, 70%%)", (tv.depth*47)%360))
+}
+
+func (tv *TreemapVisitor) visitDirectory(directory *Directory) {
+	if tv.depth == 0 {
+		fmt.Fprintf(tv.out, "<svg xmlns=\"http://www.w3.org/2000/svg\" width=\"%g\" height=\"%g\">\n",
+			tv.rect.w, tv.rect.h)
+	}
+	tv.drawRect(directory.getPath(), directory.size, "none")
+
+	var children []FileSystemNode
+	var areas []float64
+	for _, child := range directory.sortedChildren() {
+		if child.getSize() > 0 {
+			children = append(children, child)
+		}
+	}
+	sort.SliceStable(children, func(i, j int) bool {
+		return children[i].getSize() > children[j].getSize()
+	})
+
+	inner := Rect{
+		tv.rect.x + tv.padding, tv.rect.y + tv.padding,
+		math.Max(tv.rect.w-2*tv.padding, 0), math.Max(tv.rect.h-2*tv.padding, 0),
+	}
+	for _, child := range children {
+		areas = append(areas, float64(child.getSize())/float64(directory.size)*inner.w*inner.h)
+	}
+
+	rect := tv.rect
+	tv.depth += 1
+	for i, childRect := range squarify(areas, inner) {
+		tv.rect = childRect
+		children[i].accept(tv)
+	}
+	tv.depth -= 1
+	tv.rect = rect
+
+	if tv.depth == 0 {
+		fmt.Fprintln(tv.out, "</svg>")
+	}
+}
+
+func (tv *TreemapVisitor) drawRect(path string, size int64, fill string) {
+	fmt.Fprintf(tv.out,
+		"<rect x=\"%.2f\" y=\"%.2f\" width=\"%.2f\" height=\"%.2f\" fill=\"%s\" stroke=\"black\" stroke-width=\"0.5\"><title>%s (%d)</title></rect>\n",
+		tv.rect.x, tv.rect.y, tv.rect.w, tv.rect.h, fill, html.EscapeString(path), size)
+}
+
+func squarify(areas []float64, rect Rect) []Rect {
+	rects := make([]Rect, 0, len(areas))
+	for start := 0; start < len(areas); {
+		side := math.Min(rect.w, rect.h)
+		end := start + 1
+		for end < len(areas) &&
+			worstRatio(areas[start:end+1], side) <= worstRatio(areas[start:end], side) {
+			end++
+		}
+
+		row := areas[start:end]
+		rowArea := 0.0
+		for _, area := range row {
+			rowArea += area
+		}
+
+		if rect.w >= rect.h {
+			rowWidth := 0.0
+			if rect.h > 0 {
+				rowWidth = rowArea / rect.h
+			}
+			y := rect.y
+			for _, area := range row {
+				h := 0.0
+				if rowWidth > 0 {
+					h = area / rowWidth
+				}
+				rects = append(rects, Rect{rect.x, y, rowWidth, h})
+				y += h
+			}
+			rect = Rect{rect.x + rowWidth, rect.y, math.Max(rect.w-rowWidth, 0), rect.h}
+		} else {
+			rowHeight := 0.0
+			if rect.w > 0 {
+				rowHeight = rowArea / rect.w
+			}
+			x := rect.x
+			for _, area := range row {
+				w := 0.0
+				if rowHeight > 0 {
+					w = area / rowHeight
+				}
+				rects = append(rects, Rect{x, rect.y, w, rowHeight})
+				x += w
+			}
+			rect = Rect{rect.x, rect.y + rowHeight, rect.w, math.Max(rect.h-rowHeight, 0)}
+		}
+
+		start = end
+	}
+
+	return rects
+}
+
+func worstRatio(row []float64, side float64) float64 {
+	sum, minArea, maxArea := 0.0, math.Inf(1), 0.0
+	for _, area := range row {
+		sum += area
+		minArea = math.Min(minArea, area)
+		maxArea = math.Max(maxArea, area)
+	}
+	if sum == 0 || minArea == 0 {
+		return math.Inf(1)
+	}
+
+	side2, sum2 := side*side, sum*sum
+	return math.Max(side2*maxArea/sum2, sum2/(side2*minArea))
+}
@@ -2,9 +2,12 @@ package main
 
 import (
 	"bufio"
+	"flag"
 	"fmt"
 	"log"
+	"net/http"
 	"os"
+	"sort"
 	"strconv"
 	"strings"
 )
@@ -63,6 +66,9 @@ func (tdv *ToDeleteVisitor) visitDirectory(directory *Directory) {
 type FileSystemNode interface {
 	accept(visitor Visitor)
 	getSize() int64
+	getName() string
+	getParent() *Directory
+	getPath() string
 }
 
 type FileSystemNodeBase struct {
@@ -75,6 +81,26 @@ func (n *FileSystemNodeBase) getSize() int64 {
 	return n.size
 }
 
+func (n *FileSystemNodeBase) getName() string {
+	return n.name
+}
+
+func (n *FileSystemNodeBase) getParent() *Directory {
+	return n.parent
+}
+
+func (n *FileSystemNodeBase) getPath() string {
+	if n.parent == nil {
+		return "/"
+	}
+
+	parentPath := n.parent.getPath()
+	if parentPath == "/" {
+		return "/" + n.name
+	}
+	return parentPath + "/" + n.name
+}
+
 type File struct {
 	FileSystemNodeBase
 }
@@ -96,6 +122,7 @@ func newFile(name string, size int64, parent *Directory) *File {
 type Directory struct {
 	FileSystemNodeBase
 	children map[string]FileSystemNode
+	listed   bool
 }
 
 func (d *Directory) accept(vistor Visitor) {
@@ -113,97 +140,369 @@ func newDirectory(name string, parent *Directory) *Directory {
 	}
 }
 
-type Command interface {
-	parse(scanner *bufio.Scanner) bool
-	run(current *Directory, root *Directory) *Directory
+func (d *Directory) sortedChildren() []FileSystemNode {
+	names := make([]string, 0, len(d.children))
+	for name := range d.children {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	children := make([]FileSystemNode, len(names))
+	for i, name := range names {
+		children[i] = d.children[name]
+	}
+	return children
 }
 
-type CdCommand struct {
-	arg string
+type Warning struct {
+	line    int
+	message string
 }
 
-func (c *CdCommand) parse(scanner *bufio.Scanner) bool {
-	c.arg = strings.Split(scanner.Text(), " ")[2]
-	return scanner.Scan()
+func (w Warning) String() string {
+	return fmt.Sprintf("line %d: %s", w.line, w.message)
 }
 
-func (c *CdCommand) run(current *Directory, root *Directory) *Directory {
-	switch c.arg {
-	case "..":
-		return current.parent
-	case "/":
-		return root
-	default:
-		return current.children[c.arg].(*Directory)
-	}
+type Command interface {
+	parse(scanner *bufio.Scanner) bool
+	run(current *Directory, root *Directory) (*Directory, []string)
 }
 
-type LsCommand struct {
+type CommandBase struct {
+	args   []string
 	output []string
 }
 
-func (l *LsCommand) parse(scanner *bufio.Scanner) bool {
+func (c *CommandBase) parse(scanner *bufio.Scanner) bool {
+	c.args = strings.Fields(scanner.Text())[2:]
 	for scanner.Scan() {
-		if scanner.Text()[0] == '$' {
+		if strings.HasPrefix(scanner.Text(), "$") {
 			return true
 		}
 
-		l.output = append(l.output, scanner.Text())
+		if strings.TrimSpace(scanner.Text()) != "" {
+			c.output = append(c.output, scanner.Text())
+		}
 	}
 
 	return false
 }
 
-func (l *LsCommand) run(current *Directory, root *Directory) *Directory {
+func (c *CommandBase) unexpectedOutput() []string {
+	if len(c.output) == 0 {
+		return nil
+	}
+	return []string{fmt.Sprintf("ignoring %d unexpected output lines", len(c.output))}
+}
+
+type CdCommand struct {
+	CommandBase
+}
+
+func (c *CdCommand) run(current *Directory, root *Directory) (*Directory, []string) {
+	warnings := c.unexpectedOutput()
+	if len(c.args) != 1 {
+		return current, append(warnings, "cd expects exactly one argument")
+	}
+
+	arg := c.args[0]
+	switch arg {
+	case "..":
+		if current.parent == nil {
+			return current, append(warnings, "cd .. at root")
+		}
+		return current.parent, warnings
+	case "/":
+		return root, warnings
+	default:
+		child, found := current.children[arg]
+		if !found {
+			directory := newDirectory(arg, current)
+			current.children[arg] = directory
+			return directory, append(warnings,
+				fmt.Sprintf("implicitly created directory %s", directory.getPath()))
+		}
+
+		directory, isDir := child.(*Directory)
+		if !isDir {
+			return current, append(warnings,
+				fmt.Sprintf("cannot cd into file %s", child.getPath()))
+		}
+		return directory, warnings
+	}
+}
+
+type LsCommand struct {
+	CommandBase
+}
+
+func (l *LsCommand) run(current *Directory, root *Directory) (*Directory, []string) {
+	var warnings []string
+	listed := make(map[string]bool)
+
 	for _, line := range l.output {
-		splitted := strings.Split(line, " ")
+		splitted := strings.Fields(line)
+		if len(splitted) != 2 {
+			warnings = append(warnings, fmt.Sprintf("malformed ls output %q", line))
+			continue
+		}
+
 		name := splitted[1]
-		var child FileSystemNode
+		listed[name] = true
+		existing, found := current.children[name]
 
 		if splitted[0] == "dir" {
-			child = newDirectory(name, current)
-		} else {
-			size, err := strconv.ParseInt(splitted[0], 10, 64)
-			errorHandler(err)
-			child = newFile(name, size, current)
+			if _, isDir := existing.(*Directory); isDir {
+				continue
+			}
+			if found {
+				warnings = append(warnings,
+					fmt.Sprintf("%s changed from file to directory", existing.getPath()))
+			}
+			current.children[name] = newDirectory(name, current)
+			continue
+		}
+
+		size, err := strconv.ParseInt(splitted[0], 10, 64)
+		if err != nil {
+			warnings = append(warnings, fmt.Sprintf("invalid size in ls output %q", line))
+			continue
 		}
 
-		current.children[name] = child
+		if file, isFile := existing.(*File); isFile && file.size != size {
+			warnings = append(warnings, fmt.Sprintf("conflicting sizes for %s: %d and %d",
+				file.getPath(), file.size, size))
+		} else if found && !isFile {
+			warnings = append(warnings,
+				fmt.Sprintf("%s changed from directory to file", existing.getPath()))
+		}
+		current.children[name] = newFile(name, size, current)
 	}
 
-	return current
+	if current.listed {
+		for _, child := range current.sortedChildren() {
+			if !listed[child.getName()] {
+				warnings = append(warnings,
+					fmt.Sprintf("%s missing from repeated listing", child.getPath()))
+			}
+		}
+	}
+	current.listed = true
+
+	return current, warnings
 }
 
-func main() {
-	f, err := os.Open("input.txt")
-	errorHandler(err)
-	defer f.Close()
+type PwdCommand struct {
+	CommandBase
+}
+
+func (p *PwdCommand) run(current *Directory, root *Directory) (*Directory, []string) {
+	if len(p.output) != 1 || p.output[0] != current.getPath() {
+		return current, []string{fmt.Sprintf("pwd output %q does not match %s",
+			strings.Join(p.output, "\n"), current.getPath())}
+	}
+	return current, nil
+}
+
+type MkdirCommand struct {
+	CommandBase
+}
+
+func (m *MkdirCommand) run(current *Directory, root *Directory) (*Directory, []string) {
+	warnings := m.unexpectedOutput()
+	if len(m.args) == 0 {
+		return current, append(warnings, "mkdir expects at least one argument")
+	}
+
+	for _, name := range m.args {
+		if child, found := current.children[name]; found {
+			warnings = append(warnings, fmt.Sprintf("%s already exists", child.getPath()))
+			continue
+		}
+		current.children[name] = newDirectory(name, current)
+	}
+	return current, warnings
+}
+
+type RmCommand struct {
+	CommandBase
+}
+
+func (r *RmCommand) run(current *Directory, root *Directory) (*Directory, []string) {
+	warnings := r.unexpectedOutput()
+	recursive := false
+	var names []string
+	for _, arg := range r.args {
+		if arg == "-r" || arg == "-rf" {
+			recursive = true
+		} else {
+			names = append(names, arg)
+		}
+	}
+	if len(names) == 0 {
+		return current, append(warnings, "rm expects at least one argument")
+	}
+
+	for _, name := range names {
+		child, found := current.children[name]
+		if !found {
+			warnings = append(warnings, fmt.Sprintf("cannot remove missing %s", name))
+			continue
+		}
+		if _, isDir := child.(*Directory); isDir && !recursive {
+			warnings = append(warnings, fmt.Sprintf("cannot remove directory %s without -r", child.getPath()))
+			continue
+		}
+		delete(current.children, name)
+	}
+	return current, warnings
+}
 
-	scanner := bufio.NewScanner(f)
-	scanner.Scan()
+type UnknownCommand struct {
+	CommandBase
+	name string
+}
 
+func (u *UnknownCommand) run(current *Directory, root *Directory) (*Directory, []string) {
+	return current, []string{fmt.Sprintf("unknown command %q, skipped %d output lines",
+		u.name, len(u.output))}
+}
+
+func parseTranscript(scanner *bufio.Scanner) (*Directory, []Warning) {
 	commandsMap := map[string](func() Command){
-		"cd": func() Command { return &CdCommand{} },
-		"ls": func() Command { return &LsCommand{} },
+		"cd":    func() Command { return &CdCommand{} },
+		"ls":    func() Command { return &LsCommand{} },
+		"pwd":   func() Command { return &PwdCommand{} },
+		"mkdir": func() Command { return &MkdirCommand{} },
+		"rm":    func() Command { return &RmCommand{} },
 	}
 
+	line := 0
+	scanner.Split(func(data []byte, atEOF bool) (int, []byte, error) {
+		advance, token, err := bufio.ScanLines(data, atEOF)
+		if token != nil {
+			line += 1
+		}
+		return advance, token, err
+	})
+
+	var warnings []Warning
 	rootDir := newDirectory("/", nil)
 	currentDir := rootDir
-	moreCommands := true
+	moreCommands := scanner.Scan()
 	for moreCommands {
-		command := commandsMap[strings.Split(scanner.Text(), " ")[1]]()
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 2 || fields[0] != "$" {
+			if len(fields) > 0 {
+				warnings = append(warnings, Warning{line, fmt.Sprintf("ignoring %q outside command", scanner.Text())})
+			}
+			moreCommands = scanner.Scan()
+			continue
+		}
+
+		newCommand, found := commandsMap[fields[1]]
+		if !found {
+			name := fields[1]
+			newCommand = func() Command { return &UnknownCommand{name: name} }
+		}
+
+		command := newCommand()
+		commandLine := line
 		moreCommands = command.parse(scanner)
-		currentDir = command.run(currentDir, rootDir)
+
+		var messages []string
+		currentDir, messages = command.run(currentDir, rootDir)
+		for _, message := range messages {
+			warnings = append(warnings, Warning{commandLine, message})
+		}
+	}
+
+	return rootDir, warnings
+}
+
+func main() {
+	serve := flag.String("serve", "", "address to serve the reconstructed filesystem on")
+	capacity := flag.Int64("capacity", 70000000, "total disk capacity")
+	needed := flag.Int64("needed", 30000000, "free space needed")
+	plan := flag.Bool("plan", false, "print the smallest set of directories to delete")
+	planMemory := flag.Int64("plan-memory", 256, "memory limit in MiB for the deletion planner")
+	top := flag.Int("top", 0, "print the N largest directories")
+	export := flag.String("export", "", "write the tree to stdout as json, ncdu or svg")
+	flag.Parse()
+
+	f, err := os.Open("input.txt")
+	errorHandler(err)
+	defer f.Close()
+
+	rootDir, warnings := parseTranscript(bufio.NewScanner(f))
+	for _, warning := range warnings {
+		fmt.Fprintf(os.Stderr, "warning: %v\n", warning)
 	}
 
 	sizeVisitor := newSizeVisitor()
 	sizeVisitor.visitDirectory(rootDir)
+
+	switch *export {
+	case "":
+	case "json":
+		jsonVisitor := &JSONVisitor{}
+		jsonVisitor.visitDirectory(rootDir)
+		errorHandler(jsonVisitor.write(os.Stdout))
+		return
+	case "ncdu":
+		ncduVisitor := &NcduVisitor{}
+		ncduVisitor.visitDirectory(rootDir)
+		errorHandler(ncduVisitor.write(os.Stdout))
+		return
+	case "svg":
+		newTreemapVisitor(os.Stdout, 1200, 800).visitDirectory(rootDir)
+		return
+	default:
+		log.Fatalf("unknown export format %q", *export)
+	}
+
+	if flag.Arg(0) == "shell" {
+		newShell(rootDir, os.Stdout).run(os.Stdin)
+		return
+	}
+
 	fmt.Println(sizeVisitor.smallDirSizeSum)
 
-	freeNeeded := 30000000 - (70000000 - rootDir.size)
-	toDeleteVisitor := newToDeleteVisitor(freeNeeded)
-	toDeleteVisitor.visitDirectory(rootDir)
-	fmt.Println(toDeleteVisitor.toDelete.size)
+	freeNeeded := *needed - (*capacity - rootDir.size)
+	if freeNeeded <= 0 {
+		fmt.Println(0)
+	} else {
+		toDeleteVisitor := newToDeleteVisitor(freeNeeded)
+		toDeleteVisitor.visitDirectory(rootDir)
+		if toDeleteVisitor.toDelete == nil {
+			log.Fatalf("cannot free %d bytes", freeNeeded)
+		}
+		fmt.Println(toDeleteVisitor.toDelete.size)
+	}
+
+	if *plan {
+		directories, err := planDeletion(rootDir, freeNeeded, *planMemory<<20)
+		errorHandler(err)
+
+		var total int64
+		for _, directory := range directories {
+			fmt.Printf("%d\t%s\n", directory.size, directory.getPath())
+			total += directory.size
+		}
+		fmt.Printf("%d\ttotal\n", total)
+	}
+
+	if *top > 0 {
+		largestVisitor := &LargestVisitor{}
+		largestVisitor.visitDirectory(rootDir)
+		for _, directory := range largestVisitor.top(*top) {
+			fmt.Printf("%d\t%s\n", directory.size, directory.getPath())
+		}
+	}
+
+	if *serve != "" {
+		errorHandler(http.ListenAndServe(*serve, http.FileServer(http.FS(rootDir))))
+	}
 }
 
 func errorHandler(err error) {
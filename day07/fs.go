@@ -0,0 +1,170 @@
+package main
+
+import (
+	"errors"
+	"io"
+	"io/fs"
+	"strings"
+	"time"
+)
+
+type nodeInfo struct {
+	name string
+	node FileSystemNode
+}
+
+func (i *nodeInfo) Name() string {
+	return i.name
+}
+
+func (i *nodeInfo) Size() int64 {
+	return i.node.getSize()
+}
+
+func (i *nodeInfo) Mode() fs.FileMode {
+	if i.IsDir() {
+		return fs.ModeDir | 0555
+	}
+	return 0444
+}
+
+func (i *nodeInfo) ModTime() time.Time {
+	return time.Time{}
+}
+
+func (i *nodeInfo) IsDir() bool {
+	_, isDir := i.node.(*Directory)
+	return isDir
+}
+
+func (i *nodeInfo) Sys() any {
+	return i.node
+}
+
+func (i *nodeInfo) Type() fs.FileMode {
+	return i.Mode().Type()
+}
+
+func (i *nodeInfo) Info() (fs.FileInfo, error) {
+	return i, nil
+}
+
+type zeros struct{}
+
+func (zeros) ReadAt(p []byte, off int64) (int, error) {
+	for i := range p {
+		p[i] = 0
+	}
+	return len(p), nil
+}
+
+type openFile struct {
+	*io.SectionReader
+	info *nodeInfo
+}
+
+func (f *openFile) Stat() (fs.FileInfo, error) {
+	return f.info, nil
+}
+
+func (f *openFile) Close() error {
+	return nil
+}
+
+type openDirectory struct {
+	info    *nodeInfo
+	entries []fs.DirEntry
+	offset  int
+}
+
+func (d *openDirectory) Stat() (fs.FileInfo, error) {
+	return d.info, nil
+}
+
+func (d *openDirectory) Read(p []byte) (int, error) {
+	return 0, &fs.PathError{Op: "read", Path: d.info.name, Err: errors.New("is a directory")}
+}
+
+func (d *openDirectory) Close() error {
+	return nil
+}
+
+func (d *openDirectory) ReadDir(n int) ([]fs.DirEntry, error) {
+	remaining := d.entries[d.offset:]
+	if n <= 0 {
+		d.offset = len(d.entries)
+		return remaining, nil
+	}
+
+	if len(remaining) == 0 {
+		return nil, io.EOF
+	}
+	if n > len(remaining) {
+		n = len(remaining)
+	}
+	d.offset += n
+	return remaining[:n], nil
+}
+
+func (d *Directory) entries() []fs.DirEntry {
+	children := d.sortedChildren()
+	entries := make([]fs.DirEntry, len(children))
+	for i, child := range children {
+		entries[i] = &nodeInfo{child.getName(), child}
+	}
+	return entries
+}
+
+func (d *Directory) lookup(op, name string) (*nodeInfo, error) {
+	if !fs.ValidPath(name) {
+		return nil, &fs.PathError{Op: op, Path: name, Err: fs.ErrInvalid}
+	}
+	if name == "." {
+		return &nodeInfo{".", d}, nil
+	}
+
+	var node FileSystemNode = d
+	for _, part := range strings.Split(name, "/") {
+		directory, isDir := node.(*Directory)
+		if !isDir {
+			return nil, &fs.PathError{Op: op, Path: name, Err: fs.ErrNotExist}
+		}
+
+		child, found := directory.children[part]
+		if !found {
+			return nil, &fs.PathError{Op: op, Path: name, Err: fs.ErrNotExist}
+		}
+		node = child
+	}
+
+	return &nodeInfo{name[strings.LastIndex(name, "/")+1:], node}, nil
+}
+
+func (d *Directory) Open(name string) (fs.File, error) {
+	info, err := d.lookup("open", name)
+	if err != nil {
+		return nil, err
+	}
+
+	if directory, isDir := info.node.(*Directory); isDir {
+		return &openDirectory{info: info, entries: directory.entries()}, nil
+	}
+	return &openFile{io.NewSectionReader(zeros{}, 0, info.Size()), info}, nil
+}
+
+func (d *Directory) ReadDir(name string) ([]fs.DirEntry, error) {
+	info, err := d.lookup("readdir", name)
+	if err != nil {
+		return nil, err
+	}
+
+	directory, isDir := info.node.(*Directory)
+	if !isDir {
+		return nil, &fs.PathError{Op: "readdir", Path: name, Err: errors.New("not a directory")}
+	}
+	return directory.entries(), nil
+}
+
+func (d *Directory) Stat(name string) (fs.FileInfo, error) {
+	return d.lookup("stat", name)
+}
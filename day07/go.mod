@@ -0,0 +1,3 @@
+module github.com/davidaf3/advent-of-code-2022/day07
+
+go 1.19
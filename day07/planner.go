@@ -0,0 +1,171 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+)
+
+type Bitset []uint64
+
+func newBitset(size int64) Bitset {
+	return make(Bitset, (size+63)/64)
+}
+
+func (b Bitset) set(i int64) {
+	b[i/64] |= 1 << (i % 64)
+}
+
+func (b Bitset) has(i int64) bool {
+	return i >= 0 && i/64 < int64(len(b)) && b[i/64]&(1<<(i%64)) != 0
+}
+
+func (b Bitset) or(src Bitset) {
+	for i := range b {
+		b[i] |= src[i]
+	}
+}
+
+func (b Bitset) orShifted(src Bitset, shift int64) {
+	words, bits := shift/64, uint(shift%64)
+	for i := int64(len(b)) - 1; i >= words; i-- {
+		j := i - words
+		word := src[j] << bits
+		if bits > 0 && j > 0 {
+			word |= src[j-1] >> (64 - bits)
+		}
+		b[i] |= word
+	}
+}
+
+func (b Bitset) next(from, limit int64) int64 {
+	for i := from; i < limit; i++ {
+		if b[i/64] == 0 {
+			i |= 63
+			continue
+		}
+		if b.has(i) {
+			return i
+		}
+	}
+	return -1
+}
+
+type PreorderVisitor struct {
+	directories []*Directory
+	ends        []int
+}
+
+func (pv *PreorderVisitor) visitFile(file *File) {}
+
+func (pv *PreorderVisitor) visitDirectory(directory *Directory) {
+	idx := len(pv.directories)
+	pv.directories = append(pv.directories, directory)
+	pv.ends = append(pv.ends, 0)
+	for _, child := range directory.sortedChildren() {
+		child.accept(pv)
+	}
+	pv.ends[idx] = len(pv.directories)
+}
+
+func planDeletion(root *Directory, freeNeeded, maxMemory int64) ([]*Directory, error) {
+	if freeNeeded <= 0 {
+		return nil, nil
+	}
+
+	pv := &PreorderVisitor{}
+	pv.visitDirectory(root)
+
+	memory := int64(len(pv.directories)+1) * ((freeNeeded + 63) / 64) * 8
+	if memory > maxMemory {
+		return nil, fmt.Errorf("planning %d directories to free %d bytes needs %d MiB, over the %d MiB limit",
+			len(pv.directories), freeNeeded, memory>>20, maxMemory>>20)
+	}
+
+	reach := make([]Bitset, len(pv.directories)+1)
+	reach[0] = newBitset(freeNeeded)
+	reach[0].set(0)
+	bestTotal, bestPosition, bestSum := int64(-1), -1, int64(0)
+
+	for i, directory := range pv.directories {
+		if reach[i] == nil {
+			continue
+		}
+		if reach[i+1] == nil {
+			reach[i+1] = newBitset(freeNeeded)
+		}
+		reach[i+1].or(reach[i])
+
+		from := freeNeeded - directory.size
+		if from < 0 {
+			from = 0
+		}
+		if sum := reach[i].next(from, freeNeeded); sum >= 0 &&
+			(bestTotal < 0 || sum+directory.size < bestTotal) {
+			bestTotal, bestPosition, bestSum = sum+directory.size, i, sum
+		}
+
+		if end := pv.ends[i]; directory.size < freeNeeded {
+			if reach[end] == nil {
+				reach[end] = newBitset(freeNeeded)
+			}
+			reach[end].orShifted(reach[i], directory.size)
+		}
+	}
+
+	if bestTotal < 0 {
+		return nil, fmt.Errorf("cannot free %d bytes", freeNeeded)
+	}
+
+	chosenBy := make(map[int][]int)
+	for i, end := range pv.ends {
+		chosenBy[end] = append(chosenBy[end], i)
+	}
+
+	plan := []*Directory{pv.directories[bestPosition]}
+	for position, sum := bestPosition, bestSum; position > 0; {
+		if reach[position-1].has(sum) {
+			position -= 1
+			continue
+		}
+
+		for _, i := range chosenBy[position] {
+			if size := pv.directories[i].size; reach[i].has(sum - size) {
+				plan = append(plan, pv.directories[i])
+				position, sum = i, sum-size
+				break
+			}
+		}
+	}
+
+	sort.Slice(plan, func(i, j int) bool {
+		return plan[i].getPath() < plan[j].getPath()
+	})
+	return plan, nil
+}
+
+type LargestVisitor struct {
+	directories []*Directory
+}
+
+func (lv *LargestVisitor) visitFile(file *File) {}
+
+func (lv *LargestVisitor) visitDirectory(directory *Directory) {
+	lv.directories = append(lv.directories, directory)
+	for _, child := range directory.children {
+		child.accept(lv)
+	}
+}
+
+func (lv *LargestVisitor) top(n int) []*Directory {
+	sort.Slice(lv.directories, func(i, j int) bool {
+		if lv.directories[i].size == lv.directories[j].size {
+			return lv.directories[i].getPath() < lv.directories[j].getPath()
+		}
+		return lv.directories[i].size > lv.directories[j].size
+	})
+
+	if n > len(lv.directories) {
+		n = len(lv.directories)
+	}
+	return lv.directories[:n]
+}
@@ -0,0 +1,350 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+type DuVisitor struct {
+	out   io.Writer
+	human bool
+}
+
+func (dv *DuVisitor) visitFile(file *File) {}
+
+func (dv *DuVisitor) visitDirectory(directory *Directory) {
+	for _, child := range directory.sortedChildren() {
+		child.accept(dv)
+	}
+	fmt.Fprintf(dv.out, "%s\t%s\n", formatSize(directory.size, dv.human), directory.getPath())
+}
+
+type FindVisitor struct {
+	out     io.Writer
+	compare int
+	size    int64
+}
+
+func (fv *FindVisitor) visitFile(file *File) {
+	if fv.compare > 0 && file.size > fv.size ||
+		fv.compare < 0 && file.size < fv.size ||
+		fv.compare == 0 && file.size == fv.size {
+		fmt.Fprintln(fv.out, file.getPath())
+	}
+}
+
+func (fv *FindVisitor) visitDirectory(directory *Directory) {
+	for _, child := range directory.sortedChildren() {
+		child.accept(fv)
+	}
+}
+
+type TreeVisitor struct {
+	out    io.Writer
+	prefix string
+	last   bool
+	depth  int
+}
+
+func (tv *TreeVisitor) visitFile(file *File) {
+	tv.printNode(file.name, file.size)
+}
+
+func (tv *TreeVisitor) visitDirectory(directory *Directory) {
+	if tv.depth == 0 {
+		fmt.Fprintf(tv.out, "%s (%d)\n", directory.getPath(), directory.size)
+	} else {
+		tv.printNode(directory.name+"/", directory.size)
+	}
+
+	prefix := tv.prefix
+	if tv.depth > 0 {
+		if tv.last {
+			tv.prefix += "    "
+		} else {
+			tv.prefix += "│   "
+		}
+	}
+
+	children := directory.sortedChildren()
+	tv.depth += 1
+	for i, child := range children {
+		tv.last = i == len(children)-1
+		child.accept(tv)
+	}
+	tv.depth -= 1
+	tv.prefix = prefix
+}
+
+func (tv *TreeVisitor) printNode(name string, size int64) {
+	branch := "├── "
+	if tv.last {
+		branch = "└── "
+	}
+	fmt.Fprintf(tv.out, "%s%s%s (%d)\n", tv.prefix, branch, name, size)
+}
+
+func formatSize(size int64, human bool) string {
+	if !human {
+		return strconv.FormatInt(size, 10)
+	}
+
+	value := float64(size)
+	for _, unit := range []string{"", "K", "M", "G", "T"} {
+		if value < 1024 || unit == "T" {
+			if unit == "" {
+				return fmt.Sprintf("%d", size)
+			}
+			return fmt.Sprintf("%.1f%s", value, unit)
+		}
+		value /= 1024
+	}
+	return ""
+}
+
+type Shell struct {
+	root    *Directory
+	current *Directory
+	out     io.Writer
+}
+
+func newShell(root *Directory, out io.Writer) *Shell {
+	return &Shell{root, root, out}
+}
+
+func (s *Shell) run(in io.Reader) {
+	scanner := bufio.NewScanner(in)
+	for {
+		fmt.Fprintf(s.out, "%s $ ", s.current.getPath())
+		if !scanner.Scan() {
+			fmt.Fprintln(s.out)
+			return
+		}
+
+		args := strings.Fields(scanner.Text())
+		if len(args) == 0 {
+			continue
+		}
+		if args[0] == "exit" {
+			return
+		}
+		if err := s.exec(args); err != nil {
+			fmt.Fprintf(s.out, "%s: %v\n", args[0], err)
+		}
+	}
+}
+
+func (s *Shell) exec(args []string) error {
+	switch args[0] {
+	case "cd":
+		return s.cd(args[1:])
+	case "ls":
+		return s.ls(args[1:])
+	case "du":
+		return s.du(args[1:])
+	case "find":
+		return s.find(args[1:])
+	case "tree":
+		return s.tree(args[1:])
+	case "rm":
+		return s.rm(args[1:])
+	case "pwd":
+		fmt.Fprintln(s.out, s.current.getPath())
+		return nil
+	default:
+		return fmt.Errorf("command not found")
+	}
+}
+
+func (s *Shell) resolve(path string) (FileSystemNode, error) {
+	var node FileSystemNode = s.current
+	if strings.HasPrefix(path, "/") {
+		node = s.root
+	}
+
+	for _, part := range strings.Split(path, "/") {
+		if part == "" || part == "." {
+			continue
+		}
+
+		directory, isDir := node.(*Directory)
+		if !isDir {
+			return nil, fmt.Errorf("%s: not a directory", path)
+		}
+		if part == ".." {
+			if directory.parent != nil {
+				node = directory.parent
+			}
+			continue
+		}
+
+		child, found := directory.children[part]
+		if !found {
+			return nil, fmt.Errorf("%s: no such file or directory", path)
+		}
+		node = child
+	}
+
+	return node, nil
+}
+
+func (s *Shell) resolveDirectory(args []string) (*Directory, error) {
+	if len(args) == 0 {
+		return s.current, nil
+	}
+
+	node, err := s.resolve(args[0])
+	if err != nil {
+		return nil, err
+	}
+	directory, isDir := node.(*Directory)
+	if !isDir {
+		return nil, fmt.Errorf("%s: not a directory", args[0])
+	}
+	return directory, nil
+}
+
+func (s *Shell) cd(args []string) error {
+	target := "/"
+	if len(args) > 0 {
+		target = args[0]
+	}
+
+	directory, err := s.resolveDirectory([]string{target})
+	if err != nil {
+		return err
+	}
+	s.current = directory
+	return nil
+}
+
+func (s *Shell) ls(args []string) error {
+	long := len(args) > 0 && args[0] == "-l"
+	if long {
+		args = args[1:]
+	}
+
+	directory, err := s.resolveDirectory(args)
+	if err != nil {
+		return err
+	}
+
+	for _, child := range directory.sortedChildren() {
+		_, isDir := child.(*Directory)
+		switch {
+		case long && isDir:
+			fmt.Fprintf(s.out, "dir %10d %s\n", child.getSize(), child.getName())
+		case long:
+			fmt.Fprintf(s.out, "    %10d %s\n", child.getSize(), child.getName())
+		case isDir:
+			fmt.Fprintf(s.out, "%s/\n", child.getName())
+		default:
+			fmt.Fprintln(s.out, child.getName())
+		}
+	}
+	return nil
+}
+
+func (s *Shell) du(args []string) error {
+	human := len(args) > 0 && args[0] == "-h"
+	if human {
+		args = args[1:]
+	}
+
+	directory, err := s.resolveDirectory(args)
+	if err != nil {
+		return err
+	}
+
+	directory.accept(&DuVisitor{s.out, human})
+	return nil
+}
+
+func (s *Shell) find(args []string) error {
+	var path string
+	if len(args) > 0 && args[0] != "-size" {
+		path, args = args[0], args[1:]
+	}
+	if len(args) != 2 || args[0] != "-size" {
+		return fmt.Errorf("usage: find [path] -size [+-]N")
+	}
+
+	directory, err := s.resolveDirectory(strings.Fields(path))
+	if err != nil {
+		return err
+	}
+
+	visitor := &FindVisitor{out: s.out}
+	sizeArg := args[1]
+	switch sizeArg[0] {
+	case '+':
+		visitor.compare, sizeArg = 1, sizeArg[1:]
+	case '-':
+		visitor.compare, sizeArg = -1, sizeArg[1:]
+	}
+	visitor.size, err = strconv.ParseInt(sizeArg, 10, 64)
+	if err != nil {
+		return err
+	}
+
+	directory.accept(visitor)
+	return nil
+}
+
+func (s *Shell) tree(args []string) error {
+	directory, err := s.resolveDirectory(args)
+	if err != nil {
+		return err
+	}
+
+	directory.accept(&TreeVisitor{out: s.out})
+	return nil
+}
+
+func (s *Shell) rm(args []string) error {
+	recursive := len(args) > 0 && args[0] == "-r"
+	if recursive {
+		args = args[1:]
+	}
+	if len(args) == 0 {
+		return fmt.Errorf("missing operand")
+	}
+
+	for _, path := range args {
+		node, err := s.resolve(path)
+		if err != nil {
+			return err
+		}
+		if _, isDir := node.(*Directory); isDir && !recursive {
+			return fmt.Errorf("%s: is a directory", path)
+		}
+		if node.getParent() == nil {
+			return fmt.Errorf("%s: cannot remove root", path)
+		}
+		if directory, isDir := node.(*Directory); isDir && isAncestor(directory, s.current) {
+			s.current = directory.parent
+		}
+
+		removeNode(node)
+	}
+	return nil
+}
+
+func isAncestor(ancestor, directory *Directory) bool {
+	for ; directory != nil; directory = directory.parent {
+		if directory == ancestor {
+			return true
+		}
+	}
+	return false
+}
+
+func removeNode(node FileSystemNode) {
+	delete(node.getParent().children, node.getName())
+	for parent := node.getParent(); parent != nil; parent = parent.parent {
+		parent.size -= node.getSize()
+	}
+}
@@ -0,0 +1,139 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+)
+
+var compass = map[string][2]int{
+	"N":  {-1, 0},
+	"NE": {-1, 1},
+	"E":  {0, 1},
+	"SE": {1, 1},
+	"S":  {1, 0},
+	"SW": {1, -1},
+	"W":  {0, -1},
+	"NW": {-1, -1},
+}
+
+func (f *Forest) inside(i, j int) bool {
+	return i >= 0 && i < f.rows && j >= 0 && j < f.cols
+}
+
+func (f *Forest) newMatrix() [][]bool {
+	matrix := make([][]bool, f.rows)
+	for i := range matrix {
+		matrix[i] = make([]bool, f.cols)
+	}
+	return matrix
+}
+
+func (f *Forest) visibleFrom(r, c int, eye byte) ([][2]int, error) {
+	if !f.inside(r, c) {
+		return nil, fmt.Errorf("position (%d,%d) outside the %dx%d forest", r, c, f.rows, f.cols)
+	}
+
+	visible := f.newMatrix()
+	for _, direction := range compass {
+		var tallest byte
+		for i, j := r+direction[0], c+direction[1]; f.inside(i, j); i, j = i+direction[0], j+direction[1] {
+			checkTreeVisibility(i, j, f.trees[i][j], eye, &tallest, visible)
+		}
+	}
+
+	var trees [][2]int
+	for i := range visible {
+		for j := range visible[i] {
+			if visible[i][j] {
+				trees = append(trees, [2]int{i, j})
+			}
+		}
+	}
+	return trees, nil
+}
+
+func (f *Forest) topScenicSpots(k, spacing int) [][2]int {
+	var candidates [][2]int
+	for i := 0; i < f.rows; i++ {
+		for j := 0; j < f.cols; j++ {
+			candidates = append(candidates, [2]int{i, j})
+		}
+	}
+	sort.SliceStable(candidates, func(a, b int) bool {
+		return f.scores[candidates[a][0]][candidates[a][1]] > f.scores[candidates[b][0]][candidates[b][1]]
+	})
+
+	var spots [][2]int
+	for _, candidate := range candidates {
+		if len(spots) == k {
+			break
+		}
+
+		farEnough := true
+		for _, spot := range spots {
+			if chebyshev(spot, candidate) < spacing {
+				farEnough = false
+				break
+			}
+		}
+		if farEnough {
+			spots = append(spots, candidate)
+		}
+	}
+	return spots
+}
+
+func chebyshev(a, b [2]int) int {
+	return max(abs(a[0]-b[0]), abs(a[1]-b[1]))
+}
+
+func (f *Forest) visibleFromOutside(direction [2]int) [][]bool {
+	visible := f.newMatrix()
+	di, dj := -direction[0], -direction[1]
+	for i := 0; i < f.rows; i++ {
+		for j := 0; j < f.cols; j++ {
+			if f.inside(i-di, j-dj) {
+				continue
+			}
+
+			var tallest byte
+			for x, y := i, j; f.inside(x, y); x, y = x+di, y+dj {
+				checkTreeVisibility(x, y, f.trees[x][y], 0, &tallest, visible)
+			}
+		}
+	}
+	return visible
+}
+
+func writeMatrix(w io.Writer, matrix [][]bool) error {
+	for _, row := range matrix {
+		var line strings.Builder
+		for _, cell := range row {
+			if cell {
+				line.WriteByte('#')
+			} else {
+				line.WriteByte('.')
+			}
+		}
+		if _, err := fmt.Fprintln(w, line.String()); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
+
+func max(a, b int) int {
+	if a > b {
+		return a
+	}
+	return b
+}
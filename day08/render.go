@@ -0,0 +1,153 @@
+package main
+
+import (
+	"image"
+	"image/color"
+	"image/png"
+	"io"
+	"math"
+	"os"
+	"path/filepath"
+)
+
+const cellSize = 8
+
+var directions = [4][2]int{{-1, 0}, {1, 0}, {0, -1}, {0, 1}}
+
+func (f *Forest) viewingDistance(i, j, di, dj int) int {
+	height := f.trees[i][j]
+	distance := 0
+	for x, y := i+di, j+dj; x >= 0 && x < f.rows && y >= 0 && y < f.cols; x, y = x+di, y+dj {
+		distance += 1
+		if f.trees[x][y] >= height {
+			break
+		}
+	}
+	return distance
+}
+
+func (f *Forest) writeASCII(w io.Writer) error {
+	line := make([]byte, f.cols+1)
+	line[f.cols] = '\n'
+	for i := 0; i < f.rows; i++ {
+		for j := 0; j < f.cols; j++ {
+			switch {
+			case [2]int{i, j} == f.bestTreehouse:
+				line[j] = 'X'
+			case f.visible[i][j]:
+				line[j] = '#'
+			default:
+				line[j] = '.'
+			}
+		}
+		if _, err := w.Write(line); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (f *Forest) visibilityImage() *image.RGBA {
+	img := image.NewRGBA(image.Rect(0, 0, f.cols*cellSize, f.rows*cellSize))
+	for i := 0; i < f.rows; i++ {
+		for j := 0; j < f.cols; j++ {
+			shade := uint8(40 + (f.trees[i][j]-'0')*15)
+			c := color.RGBA{shade / 3, shade / 3, shade / 3, 255}
+			if f.visible[i][j] {
+				c = color.RGBA{shade / 4, shade, shade / 4, 255}
+			}
+			fillCell(img, i, j, c)
+		}
+	}
+
+	f.drawTreehouse(img)
+	return img
+}
+
+func (f *Forest) heatmapImage() *image.RGBA {
+	img := image.NewRGBA(image.Rect(0, 0, f.cols*cellSize, f.rows*cellSize))
+	maxLog := math.Log1p(float64(f.bestScore))
+	for i := 0; i < f.rows; i++ {
+		for j := 0; j < f.cols; j++ {
+			t := 0.0
+			if maxLog > 0 {
+				t = math.Log1p(float64(f.scores[i][j])) / maxLog
+			}
+			fillCell(img, i, j, heatColor(t))
+		}
+	}
+
+	f.drawTreehouse(img)
+	return img
+}
+
+func (f *Forest) drawTreehouse(img *image.RGBA) {
+	i, j := f.bestTreehouse[0], f.bestTreehouse[1]
+	sightLine := color.RGBA{255, 255, 0, 255}
+	for _, direction := range directions {
+		distance := f.viewingDistance(i, j, direction[0], direction[1])
+		for p := 1; p <= distance*cellSize; p++ {
+			img.Set(j*cellSize+cellSize/2+direction[1]*p, i*cellSize+cellSize/2+direction[0]*p, sightLine)
+		}
+	}
+
+	fillCell(img, i, j, color.RGBA{255, 255, 255, 255})
+	for k := 1; k < cellSize-1; k++ {
+		img.Set(j*cellSize+k, i*cellSize+k, color.RGBA{255, 0, 0, 255})
+		img.Set(j*cellSize+cellSize-1-k, i*cellSize+k, color.RGBA{255, 0, 0, 255})
+	}
+}
+
+func fillCell(img *image.RGBA, i, j int, c color.RGBA) {
+	for y := i * cellSize; y < (i+1)*cellSize; y++ {
+		for x := j * cellSize; x < (j+1)*cellSize; x++ {
+			img.SetRGBA(x, y, c)
+		}
+	}
+}
+
+func heatColor(t float64) color.RGBA {
+	stops := []color.RGBA{
+		{0, 0, 64, 255},
+		{0, 128, 255, 255},
+		{0, 200, 0, 255},
+		{255, 220, 0, 255},
+		{255, 0, 0, 255},
+	}
+
+	t = math.Max(0, math.Min(1, t)) * float64(len(stops)-1)
+	idx := int(t)
+	if idx == len(stops)-1 {
+		return stops[idx]
+	}
+
+	frac := t - float64(idx)
+	lerp := func(a, b uint8) uint8 {
+		return uint8(float64(a) + (float64(b)-float64(a))*frac)
+	}
+	a, b := stops[idx], stops[idx+1]
+	return color.RGBA{lerp(a.R, b.R), lerp(a.G, b.G), lerp(a.B, b.B), 255}
+}
+
+func writePNG(path string, img image.Image) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+
+	if err := png.Encode(f, img); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+func (f *Forest) render(dir string, ascii io.Writer) error {
+	if err := f.writeASCII(ascii); err != nil {
+		return err
+	}
+	if err := writePNG(filepath.Join(dir, "visible.png"), f.visibilityImage()); err != nil {
+		return err
+	}
+	return writePNG(filepath.Join(dir, "scenic.png"), f.heatmapImage())
+}
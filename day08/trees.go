@@ -2,123 +2,124 @@ package main
 
 import (
 	"bufio"
+	"errors"
+	"flag"
 	"fmt"
 	"log"
 	"os"
+	"strings"
 )
 
-func checkTreeVisibility(i, j int, height byte, tallest *byte, visible [][]bool) {
-	if height > *tallest {
-		visible[i][j] = true
-		*tallest = height
-	}
+type Forest struct {
+	trees         [][]byte
+	rows          int
+	cols          int
+	visible       [][]bool
+	scores        [][]int
+	nVisible      int
+	bestScore     int
+	bestTreehouse [2]int
 }
 
-func visibleTrees(trees [][]byte) int {
-	w := len(trees[0])
-	h := len(trees)
-	visible := make([][]bool, h)
-	for i := range visible {
-		visible[i] = make([]bool, w)
-		visible[i][0] = true
-		visible[i][w-1] = true
-		if i == 0 || i == h-1 {
-			for j := 1; j < w-1; j++ {
-				visible[i][j] = true
-			}
-		}
-	}
+type stackEntry struct {
+	pos    int
+	height byte
+}
 
-	for j := 1; j < w-1; j++ {
-		tallest := trees[0][j]
-		for i := 1; i < h-1; i++ {
-			checkTreeVisibility(i, j, trees[i][j], &tallest, visible)
-		}
+func newForest(trees [][]byte) (*Forest, error) {
+	if len(trees) == 0 || len(trees[0]) == 0 {
+		return nil, errors.New("empty forest")
 	}
 
-	for j := 1; j < w-1; j++ {
-		tallest := trees[h-1][j]
-		for i := h - 2; i > 0; i-- {
-			checkTreeVisibility(i, j, trees[i][j], &tallest, visible)
+	rows, cols := len(trees), len(trees[0])
+	for i, row := range trees {
+		if len(row) != cols {
+			return nil, fmt.Errorf("row %d has %d trees, expected %d", i+1, len(row), cols)
 		}
 	}
 
-	for i := 1; i < h-1; i++ {
-		tallest := trees[i][0]
-		for j := 1; j < w-1; j++ {
-			checkTreeVisibility(i, j, trees[i][j], &tallest, visible)
-		}
+	f := &Forest{
+		trees:   trees,
+		rows:    rows,
+		cols:    cols,
+		visible: make([][]bool, rows),
+		scores:  make([][]int, rows),
 	}
-
-	totalVisble := w*2 + (h-2)*2
-	for i := 1; i < h-1; i++ {
-		tallest := trees[i][w-1]
-		for j := w - 2; j > 0; j-- {
-			checkTreeVisibility(i, j, trees[i][j], &tallest, visible)
-			if visible[i][j] {
-				totalVisble += 1
-			}
+	for i := range trees {
+		f.visible[i] = make([]bool, cols)
+		f.scores[i] = make([]int, cols)
+		for j := range f.scores[i] {
+			f.scores[i][j] = 1
 		}
 	}
 
-	return totalVisble
+	f.survey()
+	return f, nil
 }
 
-func getScenicScore(trees [][]byte, i, j int) int {
-	w := len(trees[0])
-	h := len(trees)
-	treeHeight := trees[i][j]
-	left := 0
-	right := 0
-	up := 0
-	down := 0
-
-	for x := i - 1; x >= 0; x-- {
-		left += 1
-		if trees[x][j] >= treeHeight {
-			break
+func checkTreeVisibility(i, j int, height, eye byte, tallest *byte, visible [][]bool) {
+	if height > *tallest {
+		visible[i][j] = true
+		if height >= eye {
+			*tallest = height
 		}
 	}
+}
 
-	for x := i + 1; x < w; x++ {
-		right += 1
-		if trees[x][j] >= treeHeight {
-			break
-		}
+func (f *Forest) survey() {
+	stack := make([]stackEntry, 0, f.rows+f.cols)
+	for i := 0; i < f.rows; i++ {
+		stack = f.scanLine(i, 0, 0, 1, f.cols, stack)
+		stack = f.scanLine(i, f.cols-1, 0, -1, f.cols, stack)
 	}
-
-	for y := j - 1; y >= 0; y-- {
-		up += 1
-		if trees[i][y] >= treeHeight {
-			break
-		}
+	for j := 0; j < f.cols; j++ {
+		stack = f.scanLine(0, j, 1, 0, f.rows, stack)
+		stack = f.scanLine(f.rows-1, j, -1, 0, f.rows, stack)
 	}
 
-	for y := j + 1; y < h; y++ {
-		down += 1
-		if trees[i][y] >= treeHeight {
-			break
+	for i := 0; i < f.rows; i++ {
+		for j := 0; j < f.cols; j++ {
+			if f.visible[i][j] {
+				f.nVisible += 1
+			}
+			if f.scores[i][j] > f.bestScore {
+				f.bestScore = f.scores[i][j]
+				f.bestTreehouse = [2]int{i, j}
+			}
 		}
 	}
-
-	return left * right * up * down
 }
 
-func maxScenicScore(trees [][]byte) int {
-	max := 0
-	for i := 0; i < len(trees); i++ {
-		for j := 0; j < len(trees[i]); j++ {
-			score := getScenicScore(trees, i, j)
-			if score > max {
-				max = score
-			}
+func (f *Forest) scanLine(i, j, di, dj, n int, stack []stackEntry) []stackEntry {
+	var tallest byte
+	stack = stack[:0]
+	for pos := 0; pos < n; pos, i, j = pos+1, i+di, j+dj {
+		height := f.trees[i][j]
+		checkTreeVisibility(i, j, height, 0, &tallest, f.visible)
+
+		for len(stack) > 0 && stack[len(stack)-1].height < height {
+			stack = stack[:len(stack)-1]
 		}
+
+		distance := pos
+		if len(stack) > 0 {
+			distance = pos - stack[len(stack)-1].pos
+		}
+		f.scores[i][j] *= distance
+		stack = append(stack, stackEntry{pos, height})
 	}
 
-	return max
+	return stack
 }
 
 func main() {
+	render := flag.String("render", "", "directory to write visibility and scenic score maps to")
+	from := flag.String("from", "", "list trees visible from row,col at eye height h (r,c,h)")
+	top := flag.Int("top", 0, "list the K best scenic spots")
+	spacing := flag.Int("spacing", 1, "minimum distance between scenic spots")
+	observer := flag.String("observer", "", "show trees visible from outside the forest (N, NE, E, ...)")
+	flag.Parse()
+
 	f, err := os.Open("input.txt")
 	errorHandler(err)
 	defer f.Close()
@@ -130,9 +131,41 @@ func main() {
 		copy(line, scanner.Bytes())
 		trees = append(trees, line)
 	}
+	errorHandler(scanner.Err())
+
+	forest, err := newForest(trees)
+	errorHandler(err)
+
+	fmt.Println(forest.nVisible)
+	fmt.Println(forest.bestScore)
+
+	if *from != "" {
+		var r, c, h int
+		_, err := fmt.Sscanf(*from, "%d,%d,%d", &r, &c, &h)
+		errorHandler(err)
+		visible, err := forest.visibleFrom(r, c, byte('0'+h))
+		errorHandler(err)
+		for _, tree := range visible {
+			fmt.Println(tree[0], tree[1], string(forest.trees[tree[0]][tree[1]]))
+		}
+		fmt.Println(len(visible))
+	}
+
+	for _, spot := range forest.topScenicSpots(*top, *spacing) {
+		fmt.Println(spot[0], spot[1], forest.scores[spot[0]][spot[1]])
+	}
 
-	fmt.Println(visibleTrees(trees))
-	fmt.Println(maxScenicScore(trees))
+	if *observer != "" {
+		direction, found := compass[strings.ToUpper(*observer)]
+		if !found {
+			log.Fatalf("unknown compass direction %q", *observer)
+		}
+		errorHandler(writeMatrix(os.Stdout, forest.visibleFromOutside(direction)))
+	}
+
+	if *render != "" {
+		errorHandler(forest.render(*render, os.Stdout))
+	}
 }
 
 func errorHandler(err error) {
@@ -0,0 +1,185 @@
+package main
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+	"io"
+	"math"
+	"os"
+	"strings"
+)
+
+const cellSize = 4
+
+type Bounds struct {
+	minX, maxX, minY, maxY int
+}
+
+func (r *Rope) bounds() Bounds {
+	b := Bounds{0, 0, 0, 0}
+	for _, trajectory := range r.trajectories {
+		for _, p := range trajectory {
+			b.minX, b.maxX = min(b.minX, p.x), max(b.maxX, p.x)
+			b.minY, b.maxY = min(b.minY, p.y), max(b.maxY, p.y)
+		}
+	}
+	return b
+}
+
+func knotLabel(knot int) byte {
+	switch {
+	case knot == 0:
+		return 'H'
+	case knot < 10:
+		return byte('0' + knot)
+	default:
+		return byte('a' + knot - 10)
+	}
+}
+
+func (r *Rope) visitedGrid(b Bounds) [][]int {
+	grid := make([][]int, b.maxY-b.minY+1)
+	for y := range grid {
+		grid[y] = make([]int, b.maxX-b.minX+1)
+		for x := range grid[y] {
+			grid[y][x] = -1
+		}
+	}
+
+	for knot, visits := range r.knotVisits {
+		for packed := range visits {
+			p := unpackPoint(packed)
+			grid[b.maxY-p.y][p.x-b.minX] = knot
+		}
+	}
+	return grid
+}
+
+func (r *Rope) writeVisited(w io.Writer) error {
+	b := r.bounds()
+	for y, row := range r.visitedGrid(b) {
+		line := make([]byte, len(row))
+		for x, knot := range row {
+			switch {
+			case x+b.minX == 0 && b.maxY-y == 0:
+				line[x] = 's'
+			case knot < 0:
+				line[x] = '.'
+			default:
+				line[x] = knotLabel(knot)
+			}
+		}
+		if _, err := fmt.Fprintln(w, string(line)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func knotColor(knot, nKnots int, intensity float64) color.RGBA {
+	hue := float64(knot) / float64(nKnots) * 6
+	v := 0.35 + 0.65*intensity
+	sector := int(hue)
+	f := hue - float64(sector)
+	p, q, t := 0.0, v*(1-f), v*f
+
+	var rgb [3]float64
+	switch sector % 6 {
+	case 0:
+		rgb = [3]float64{v, t, p}
+	case 1:
+		rgb = [3]float64{q, v, p}
+	case 2:
+		rgb = [3]float64{p, v, t}
+	case 3:
+		rgb = [3]float64{p, q, v}
+	case 4:
+		rgb = [3]float64{t, p, v}
+	default:
+		rgb = [3]float64{v, p, q}
+	}
+	return color.RGBA{uint8(rgb[0] * 255), uint8(rgb[1] * 255), uint8(rgb[2] * 255), 255}
+}
+
+func (r *Rope) visitedImage() *image.RGBA {
+	b := r.bounds()
+	grid := r.visitedGrid(b)
+	img := image.NewRGBA(image.Rect(0, 0, len(grid[0])*cellSize, len(grid)*cellSize))
+
+	projected := make([]Visits, len(r.knots))
+	maxCounts := make([]int, len(r.knots))
+	for knot, visits := range r.knotVisits {
+		projected[knot] = Visits{}
+		for packed, count := range visits {
+			p := unpackPoint(packed)
+			key := Point{p.x, p.y, 0}.pack()
+			projected[knot][key] += count
+			maxCounts[knot] = max(maxCounts[knot], projected[knot][key])
+		}
+	}
+
+	for y, row := range grid {
+		for x, knot := range row {
+			c := color.RGBA{0, 0, 0, 255}
+			if knot >= 0 {
+				count := projected[knot][Point{x + b.minX, b.maxY - y, 0}.pack()]
+				intensity := math.Log1p(float64(count)) / math.Log1p(float64(maxCounts[knot]))
+				c = knotColor(knot, len(r.knots), intensity)
+			}
+			if x+b.minX == 0 && b.maxY-y == 0 {
+				c = color.RGBA{255, 255, 255, 255}
+			}
+
+			for py := y * cellSize; py < (y+1)*cellSize; py++ {
+				for px := x * cellSize; px < (x+1)*cellSize; px++ {
+					img.SetRGBA(px, py, c)
+				}
+			}
+		}
+	}
+	return img
+}
+
+func (r *Rope) writeVisitedPNG(path string) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+
+	if err := png.Encode(f, r.visitedImage()); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+func (r *Rope) writeFrames(w io.Writer, instructions []string, ends []int) error {
+	b := r.bounds()
+	for i, instruction := range instructions {
+		if _, err := fmt.Fprintf(w, "== %s ==\n\n", instruction); err != nil {
+			return err
+		}
+
+		grid := make([][]byte, b.maxY-b.minY+1)
+		for y := range grid {
+			grid[y] = []byte(strings.Repeat(".", b.maxX-b.minX+1))
+		}
+		grid[b.maxY][-b.minX] = 's'
+		for knot := len(r.knots) - 1; knot >= 0; knot-- {
+			p := r.trajectories[knot][ends[i]]
+			grid[b.maxY-p.y][p.x-b.minX] = knotLabel(knot)
+		}
+
+		for _, row := range grid {
+			if _, err := fmt.Fprintln(w, string(row)); err != nil {
+				return err
+			}
+		}
+		if _, err := fmt.Fprintln(w); err != nil {
+			return err
+		}
+	}
+	return nil
+}
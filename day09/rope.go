@@ -2,6 +2,7 @@ package main
 
 import (
 	"bufio"
+	"flag"
 	"fmt"
 	"log"
 	"os"
@@ -9,21 +10,113 @@ import (
 	"strings"
 )
 
+const (
+	coordBits   = 21
+	coordOffset = 1 << (coordBits - 1)
+	coordMask   = 1<<coordBits - 1
+)
+
 type Point struct {
 	x int
 	y int
+	z int
+}
+
+func (p Point) add(other Point) Point {
+	return Point{p.x + other.x, p.y + other.y, p.z + other.z}
+}
+
+func (p Point) scale(n int) Point {
+	return Point{p.x * n, p.y * n, p.z * n}
+}
+
+func (p Point) packable() bool {
+	for _, coord := range []int{p.x, p.y, p.z} {
+		if coord < -coordOffset || coord >= coordOffset {
+			return false
+		}
+	}
+	return true
+}
+
+func (p Point) pack() uint64 {
+	return uint64(p.x+coordOffset)&coordMask<<(2*coordBits) |
+		uint64(p.y+coordOffset)&coordMask<<coordBits |
+		uint64(p.z+coordOffset)&coordMask
+}
+
+func unpackPoint(packed uint64) Point {
+	return Point{
+		int(packed>>(2*coordBits)&coordMask) - coordOffset,
+		int(packed>>coordBits&coordMask) - coordOffset,
+		int(packed&coordMask) - coordOffset,
+	}
+}
+
+type Visits map[uint64]int
+
+func (v Visits) add(p Point) {
+	v[p.pack()] += 1
 }
 
 type Rope struct {
-	knots   []Point
-	visited map[string]int
+	knots        []Point
+	slack        []int
+	visited      Visits
+	knotVisits   []Visits
+	trajectories [][]Point
 }
 
-func newRope(knots int) *Rope {
-	return &Rope{
+func newRope(knots int, slack []int, trackHistory bool) *Rope {
+	r := &Rope{
 		knots:   make([]Point, knots),
-		visited: map[string]int{"0,0": 1},
+		slack:   make([]int, knots),
+		visited: Visits{},
 	}
+	r.visited.add(Point{})
+
+	for i := 1; i < knots; i++ {
+		r.slack[i] = 1
+		if len(slack) > 0 {
+			r.slack[i] = slack[min(i, len(slack))-1]
+		}
+	}
+
+	if trackHistory {
+		r.knotVisits = make([]Visits, knots)
+		r.trajectories = make([][]Point, knots)
+		for i := range r.knots {
+			r.knotVisits[i] = Visits{}
+			r.recordKnot(i)
+		}
+	}
+
+	return r
+}
+
+func (r *Rope) recordKnot(i int) {
+	r.knotVisits[i].add(r.knots[i])
+	r.trajectories[i] = append(r.trajectories[i], r.knots[i])
+}
+
+func (r *Rope) distinctVisited(knot int) int {
+	if knot == len(r.knots)-1 {
+		return len(r.visited)
+	}
+	return len(r.knotVisits[knot])
+}
+
+func (r *Rope) trajectory(knot int) []Point {
+	return r.trajectories[knot]
+}
+
+func follow(current, prev int) int {
+	if current < prev {
+		return current + 1
+	} else if current > prev {
+		return current - 1
+	}
+	return current
 }
 
 func (r *Rope) updateTail() {
@@ -31,67 +124,193 @@ func (r *Rope) updateTail() {
 		prev := r.knots[i-1]
 		current := r.knots[i]
 
-		if prev.x > current.x+1 ||
-			prev.x < current.x-1 ||
-			prev.y > current.y+1 ||
-			prev.y < current.y-1 {
-
-			if current.x < prev.x {
-				r.knots[i].x += 1
-			} else if current.x > prev.x {
-				r.knots[i].x -= 1
-			}
-
-			if current.y < prev.y {
-				r.knots[i].y += 1
-			} else if current.y > prev.y {
-				r.knots[i].y -= 1
+		if chebyshev(prev, current) > r.slack[i] {
+			r.knots[i] = Point{
+				follow(current.x, prev.x),
+				follow(current.y, prev.y),
+				follow(current.z, prev.z),
 			}
 		}
 	}
 }
 
-var ropeMoves = map[byte](func(*Rope)){
-	'R': func(r *Rope) { r.knots[0].x += 1 },
-	'L': func(r *Rope) { r.knots[0].x -= 1 },
-	'U': func(r *Rope) { r.knots[0].y += 1 },
-	'D': func(r *Rope) { r.knots[0].y -= 1 },
+func chebyshev(a, b Point) int {
+	return max(abs(a.x-b.x), max(abs(a.y-b.y), abs(a.z-b.z)))
+}
+
+var ropeMoves = map[byte]Point{
+	'R': {1, 0, 0},
+	'L': {-1, 0, 0},
+	'U': {0, 1, 0},
+	'D': {0, -1, 0},
+	'F': {0, 0, 1},
+	'B': {0, 0, -1},
 }
 
-func (r *Rope) move(direction byte, times int) {
-	moveFn := ropeMoves[direction]
+func parseDirection(text string, allow3D bool) (Point, error) {
+	var direction Point
+	seen := make(map[byte]bool)
+	for i := 0; i < len(text); i++ {
+		delta, found := ropeMoves[text[i]]
+		if !found || seen[text[i]] {
+			return Point{}, fmt.Errorf("invalid direction %q", text)
+		}
+		if delta.z != 0 && !allow3D {
+			return Point{}, fmt.Errorf("direction %q needs 3D mode", text)
+		}
+		seen[text[i]] = true
+		direction = direction.add(delta)
+	}
+
+	if direction == (Point{}) {
+		return Point{}, fmt.Errorf("invalid direction %q", text)
+	}
+	return direction, nil
+}
+
+func (r *Rope) move(direction Point, times int) error {
+	if target := r.knots[0].add(direction.scale(times)); !target.packable() {
+		return fmt.Errorf("head would reach %d,%d,%d, outside [%d, %d)",
+			target.x, target.y, target.z, -coordOffset, coordOffset)
+	}
+
 	for i := 0; i < times; i++ {
-		moveFn(r)
+		r.knots[0] = r.knots[0].add(direction)
 		r.updateTail()
 
-		last := r.knots[len(r.knots)-1]
-		tailCoords := fmt.Sprintf("%d,%d", last.x, last.y)
-		if timesVisited, ok := r.visited[tailCoords]; !ok {
-			r.visited[tailCoords] = 1
-		} else {
-			r.visited[tailCoords] = timesVisited + 1
+		r.visited.add(r.knots[len(r.knots)-1])
+		if r.trajectories != nil {
+			for knot := range r.knots {
+				r.recordKnot(knot)
+			}
 		}
 	}
+	return nil
+}
+
+func parseInts(text string) ([]int, error) {
+	var values []int
+	for _, field := range strings.Split(text, ",") {
+		value, err := strconv.Atoi(field)
+		if err != nil {
+			return nil, err
+		}
+		values = append(values, value)
+	}
+	return values, nil
 }
 
 func main() {
+	knotCounts := flag.String("knots", "2,10", "comma-separated knot counts, one rope each")
+	slackText := flag.String("slack", "1", "comma-separated maximum slack for each following knot")
+	allow3D := flag.Bool("3d", false, "allow F/B moves along the z axis")
+	knot := flag.Int("knot", -1, "report distinct cells visited by this knot of the last rope")
+	trajectory := flag.Bool("trajectory", false, "print every position of the selected knot")
+	render := flag.String("render", "", "print the visited map of the last rope and write it as PNG to this file")
+	frames := flag.Bool("frames", false, "print the last rope after each instruction")
+	flag.Parse()
+
+	counts, err := parseInts(*knotCounts)
+	errorHandler(err)
+	slack, err := parseInts(*slackText)
+	errorHandler(err)
+
+	var ropes []*Rope
+	for i, count := range counts {
+		if count < 1 {
+			log.Fatalf("invalid knot count %d", count)
+		}
+		trackHistory := (*knot >= 0 || *render != "" || *frames) && i == len(counts)-1
+		ropes = append(ropes, newRope(count, slack, trackHistory))
+	}
+
+	lastRope := ropes[len(ropes)-1]
+	if *knot >= len(lastRope.knots) {
+		log.Fatalf("knot %d out of range [0, %d)", *knot, len(lastRope.knots))
+	}
+
 	f, err := os.Open("input.txt")
 	errorHandler(err)
 	defer f.Close()
 
-	shortRope := newRope(2)
-	longRope := newRope(10)
+	var instructions []string
+	var ends []int
 	scanner := bufio.NewScanner(f)
-	for scanner.Scan() {
-		splitted := strings.Split(scanner.Text(), " ")
+	for line := 1; scanner.Scan(); line++ {
+		splitted := strings.Fields(scanner.Text())
+		if len(splitted) != 2 {
+			log.Fatalf("line %d: invalid move %q", line, scanner.Text())
+		}
+		direction, err := parseDirection(splitted[0], *allow3D)
+		if err != nil {
+			log.Fatalf("line %d: %v", line, err)
+		}
 		times, err := strconv.Atoi(splitted[1])
-		errorHandler(err)
-		shortRope.move(splitted[0][0], times)
-		longRope.move(splitted[0][0], times)
+		if err != nil {
+			log.Fatalf("line %d: %v", line, err)
+		}
+		if times < 0 {
+			log.Fatalf("line %d: negative move count %d", line, times)
+		}
+
+		for _, rope := range ropes {
+			if err := rope.move(direction, times); err != nil {
+				log.Fatalf("line %d: %v", line, err)
+			}
+		}
+		if *frames {
+			instructions = append(instructions, scanner.Text())
+			ends = append(ends, len(lastRope.trajectory(0))-1)
+		}
+	}
+	errorHandler(scanner.Err())
+
+	for _, rope := range ropes {
+		fmt.Println(len(rope.visited))
+	}
+
+	if *frames {
+		errorHandler(lastRope.writeFrames(os.Stdout, instructions, ends))
+	}
+
+	if *render != "" {
+		errorHandler(lastRope.writeVisited(os.Stdout))
+		errorHandler(lastRope.writeVisitedPNG(*render))
+	}
+
+	if *knot >= 0 {
+		fmt.Println(lastRope.distinctVisited(*knot))
+		if *trajectory {
+			for _, p := range lastRope.trajectory(*knot) {
+				if *allow3D {
+					fmt.Printf("%d,%d,%d\n", p.x, p.y, p.z)
+				} else {
+					fmt.Printf("%d,%d\n", p.x, p.y)
+				}
+			}
+		}
 	}
+}
 
-	fmt.Println(len(shortRope.visited))
-	fmt.Println(len(longRope.visited))
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
+
+func min(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
+
+func max(a, b int) int {
+	if a > b {
+		return a
+	}
+	return b
 }
 
 func errorHandler(err error) {
@@ -1,7 +1,8 @@
 package main
 
 import (
-	"bufio"
+	"encoding/json"
+	"flag"
 	"fmt"
 	"log"
 	"os"
@@ -9,75 +10,163 @@ import (
 	"strings"
 )
 
-type CPU struct {
-	x                 int
-	nCycles           int
+type SignalProbe struct {
+	start             int
+	every             int
 	signalStrenghtSum int
-	screen            [][]byte
 }
 
-func newCPU() *CPU {
-	screen := make([][]byte, 6)
-	for i := range screen {
-		for j := 0; j < 40; j++ {
-			screen[i] = append(screen[i], '.')
-		}
+func (p *SignalProbe) reset() {
+	p.signalStrenghtSum = 0
+}
+
+func (p *SignalProbe) tick(vm *VM) {
+	if vm.cycle >= p.start && (vm.cycle-p.start)%p.every == 0 {
+		p.signalStrenghtSum += vm.registers[0] * vm.cycle
 	}
+}
 
-	return &CPU{1, 0, 0, screen}
+type CycleProbe struct {
+	cycles  map[int]bool
+	samples [][2]int
 }
 
-func (c *CPU) cycle() {
-	c.nCycles++
-	if c.nCycles%20 == 0 && c.nCycles%40 != 0 {
-		c.signalStrenghtSum += c.x * c.nCycles
+func newCycleProbe(cycles []int) *CycleProbe {
+	probe := &CycleProbe{cycles: make(map[int]bool)}
+	for _, cycle := range cycles {
+		probe.cycles[cycle] = true
 	}
+	return probe
+}
 
-	col := (c.nCycles - 1) % 40
-	if c.x == col || c.x == col+1 || c.x == col-1 {
-		row := (c.nCycles - 1) / 40
-		c.screen[row][col] = '#'
+func (p *CycleProbe) reset() {
+	p.samples = nil
+}
+
+func (p *CycleProbe) tick(vm *VM) {
+	if p.cycles[vm.cycle] {
+		p.samples = append(p.samples, [2]int{vm.cycle, vm.registers[0]})
 	}
 }
 
-func (c *CPU) addx(n int) {
-	c.cycle()
-	c.cycle()
-	c.x += n
+type CRT struct {
+	width       int
+	spriteWidth int
+	screen      [][]byte
+}
+
+func newCRT(width, height, spriteWidth int) *CRT {
+	crt := &CRT{width, spriteWidth, make([][]byte, height)}
+	crt.reset()
+	return crt
 }
 
-func (c *CPU) noop() {
-	c.cycle()
+func (c *CRT) reset() {
+	for i := range c.screen {
+		c.screen[i] = c.screen[i][:0]
+		for j := 0; j < c.width; j++ {
+			c.screen[i] = append(c.screen[i], '.')
+		}
+	}
 }
 
-func (c *CPU) run(inst string) {
-	splitted := strings.Split(inst, " ")
+func (c *CRT) tick(vm *VM) {
+	x := vm.registers[0]
+	col := (vm.cycle - 1) % c.width
+	row := (vm.cycle - 1) / c.width
+	if row < len(c.screen) && col >= x-(c.spriteWidth-1)/2 && col <= x+c.spriteWidth/2 {
+		c.screen[row][col] = '#'
+	}
+}
+
+func parseCycles(text string) ([]int, error) {
+	if text == "" {
+		return nil, nil
+	}
 
-	switch splitted[0] {
-	case "addx":
-		n, err := strconv.Atoi(splitted[1])
-		errorHandler(err)
-		c.addx(n)
-	case "noop":
-		c.noop()
+	var cycles []int
+	for _, field := range strings.Split(text, ",") {
+		cycle, err := strconv.Atoi(field)
+		if err != nil {
+			return nil, err
+		}
+		cycles = append(cycles, cycle)
 	}
+	return cycles, nil
 }
 
 func main() {
+	debug := flag.Bool("debug", false, "start the interactive debugger")
+	trace := flag.Bool("trace", false, "dump a cycle-accurate trace to stderr")
+	jsonOutput := flag.Bool("json", false, "print the answers as JSON")
+	width := flag.Int("width", 40, "CRT width in pixels")
+	height := flag.Int("height", 6, "CRT height in pixels")
+	spriteWidth := flag.Int("sprite-width", 3, "sprite width in pixels")
+	sampleStart := flag.Int("sample-start", 20, "first cycle sampled for signal strength")
+	sampleEvery := flag.Int("sample-every", 40, "cycles between signal strength samples")
+	probeCycles := flag.String("probe", "", "comma-separated cycles to record x at")
+	maxCycles := flag.Int("max-cycles", 1000000, "stop with an error after this many cycles (0 for no limit)")
+	flag.Parse()
+
 	f, err := os.Open("input.txt")
 	errorHandler(err)
 	defer f.Close()
 
-	cpu := newCPU()
-	scanner := bufio.NewScanner(f)
-	for scanner.Scan() {
-		cpu.run(scanner.Text())
+	program, err := assemble(f)
+	errorHandler(err)
+
+	if *width < 1 || *height < 1 || *spriteWidth < 1 || *sampleEvery < 1 {
+		log.Fatal("width, height, sprite width and sample interval must be positive")
 	}
+	cycles, err := parseCycles(*probeCycles)
+	errorHandler(err)
+
+	probe := &SignalProbe{start: *sampleStart, every: *sampleEvery}
+	cycleProbe := newCycleProbe(cycles)
+	crt := newCRT(*width, *height, *spriteWidth)
+	vm := newVM(program, probe, cycleProbe, crt)
+	vm.maxCycles = *maxCycles
+	if *trace {
+		vm.trace = os.Stderr
+	}
+
+	if *debug {
+		newDebugger(vm, os.Stdout).run(os.Stdin)
+		return
+	}
+
+	errorHandler(vm.run())
 
-	fmt.Println(cpu.signalStrenghtSum)
-	for _, row := range cpu.screen {
+	var part2 any
+	text, unknown, err := decodeScreen(crt.screen)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+	} else {
+		part2 = text
+	}
+	for _, glyph := range unknown {
+		fmt.Fprintf(os.Stderr, "unknown %v\n", glyph)
+	}
+
+	if *jsonOutput {
+		errorHandler(json.NewEncoder(os.Stdout).Encode(map[string]any{
+			"part1":  probe.signalStrenghtSum,
+			"part2":  part2,
+			"probes": cycleProbe.samples,
+		}))
+		return
+	}
+
+	fmt.Println(probe.signalStrenghtSum)
+	if part2 != nil {
+		fmt.Println(text)
+	}
+	for _, row := range crt.screen {
 		fmt.Println(string(row))
 	}
+	for _, sample := range cycleProbe.samples {
+		fmt.Printf("cycle %d: x=%d\n", sample[0], sample[1])
+	}
 }
 
 func errorHandler(err error) {
@@ -0,0 +1,333 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+type Expr struct {
+	text  string
+	left  string
+	op    string
+	right string
+}
+
+var exprOperators = []string{"==", "!=", "<=", ">=", "<", ">", "+", "-", "*"}
+
+func parseExpr(text string) (*Expr, error) {
+	compact := strings.ReplaceAll(text, " ", "")
+	if compact == "" {
+		return nil, fmt.Errorf("empty expression")
+	}
+	expr := &Expr{text: compact, left: compact}
+	for i := 1; i < len(compact); i++ {
+		for _, op := range exprOperators {
+			if strings.HasPrefix(compact[i:], op) {
+				expr.left, expr.op, expr.right = compact[:i], op, compact[i+len(op):]
+				break
+			}
+		}
+		if expr.op != "" {
+			break
+		}
+	}
+
+	for _, term := range []string{expr.left, expr.right} {
+		if term == "" && expr.op == "" {
+			continue
+		}
+		if !isTerm(term) {
+			return nil, fmt.Errorf("invalid expression %q", text)
+		}
+	}
+	return expr, nil
+}
+
+func isTerm(term string) bool {
+	if _, err := strconv.Atoi(term); err == nil {
+		return true
+	}
+	if _, found := registerIndex(term); found {
+		return true
+	}
+	return term == "cycle" || term == "pc"
+}
+
+func evalTerm(term string, vm *VM) int {
+	if n, err := strconv.Atoi(term); err == nil {
+		return n
+	}
+	if idx, found := registerIndex(term); found {
+		return vm.registers[idx]
+	}
+	if term == "pc" {
+		return vm.pc
+	}
+	return vm.cycle + 1
+}
+
+func boolToInt(b bool) int {
+	if b {
+		return 1
+	}
+	return 0
+}
+
+func (e *Expr) eval(vm *VM) int {
+	left := evalTerm(e.left, vm)
+	if e.op == "" {
+		return left
+	}
+
+	right := evalTerm(e.right, vm)
+	switch e.op {
+	case "==":
+		return boolToInt(left == right)
+	case "!=":
+		return boolToInt(left != right)
+	case "<=":
+		return boolToInt(left <= right)
+	case ">=":
+		return boolToInt(left >= right)
+	case "<":
+		return boolToInt(left < right)
+	case ">":
+		return boolToInt(left > right)
+	case "+":
+		return left + right
+	case "-":
+		return left - right
+	default:
+		return left * right
+	}
+}
+
+type Breakpoint struct {
+	kind   string
+	value  string
+	number int
+}
+
+func (b *Breakpoint) hit(vm *VM) bool {
+	switch b.kind {
+	case "cycle":
+		return vm.cycle+1 == b.number
+	case "line":
+		return vm.elapsed == 0 && !vm.halted() && vm.current().line == b.number
+	default:
+		return vm.elapsed == 0 && !vm.halted() && vm.current().opcode.name == b.value
+	}
+}
+
+type Watch struct {
+	expr  *Expr
+	value int
+}
+
+type Debugger struct {
+	vm          *VM
+	out         io.Writer
+	breakpoints []*Breakpoint
+	watches     []*Watch
+}
+
+func newDebugger(vm *VM, out io.Writer) *Debugger {
+	return &Debugger{vm: vm, out: out}
+}
+
+func (d *Debugger) run(in io.Reader) {
+	scanner := bufio.NewScanner(in)
+	d.status()
+	for {
+		fmt.Fprint(d.out, "(dbg) ")
+		if !scanner.Scan() {
+			fmt.Fprintln(d.out)
+			return
+		}
+
+		args := strings.Fields(scanner.Text())
+		if len(args) == 0 {
+			continue
+		}
+		if args[0] == "quit" || args[0] == "q" {
+			return
+		}
+		if err := d.exec(args); err != nil {
+			fmt.Fprintf(d.out, "error: %v\n", err)
+		}
+	}
+}
+
+func (d *Debugger) exec(args []string) error {
+	count := 1
+	if len(args) > 1 && (args[0] == "step" || args[0] == "s" || args[0] == "stepc" || args[0] == "sc") {
+		n, err := strconv.Atoi(args[1])
+		if err != nil || n < 1 {
+			return fmt.Errorf("invalid count %q", args[1])
+		}
+		count = n
+	}
+
+	switch args[0] {
+	case "step", "s":
+		for i := 0; i < count && d.vm.stepInstruction(); i++ {
+		}
+		d.updateWatches()
+		d.status()
+	case "stepc", "sc":
+		for i := 0; i < count && d.vm.stepCycle(); i++ {
+		}
+		d.updateWatches()
+		d.status()
+	case "continue", "c":
+		d.resume()
+	case "break", "b":
+		if len(args) != 3 || args[1] != "cycle" && args[1] != "line" && args[1] != "op" {
+			return fmt.Errorf("usage: break cycle|line|op VALUE")
+		}
+		breakpoint := &Breakpoint{kind: args[1], value: args[2]}
+		if args[1] != "op" {
+			n, err := strconv.Atoi(args[2])
+			if err != nil || n < 1 {
+				return fmt.Errorf("usage: break cycle|line|op VALUE")
+			}
+			breakpoint.number, breakpoint.value = n, strconv.Itoa(n)
+		}
+		d.breakpoints = append(d.breakpoints, breakpoint)
+		fmt.Fprintf(d.out, "breakpoint %d: %s %s\n", len(d.breakpoints), breakpoint.kind, breakpoint.value)
+	case "watch", "w":
+		expr, err := parseExpr(strings.Join(args[1:], " "))
+		if err != nil {
+			return err
+		}
+		d.watches = append(d.watches, &Watch{expr, expr.eval(d.vm)})
+		fmt.Fprintf(d.out, "watch %d: %s = %d\n", len(d.watches), expr.text, expr.eval(d.vm))
+	case "delete", "d":
+		return d.delete(args[1:])
+	case "info", "i":
+		for i, breakpoint := range d.breakpoints {
+			if breakpoint != nil {
+				fmt.Fprintf(d.out, "breakpoint %d: %s %s\n", i+1, breakpoint.kind, breakpoint.value)
+			}
+		}
+		for i, watch := range d.watches {
+			if watch != nil {
+				fmt.Fprintf(d.out, "watch %d: %s = %d\n", i+1, watch.expr.text, watch.expr.eval(d.vm))
+			}
+		}
+	case "print", "p":
+		expr, err := parseExpr(strings.Join(args[1:], " "))
+		if err != nil {
+			return err
+		}
+		fmt.Fprintln(d.out, expr.eval(d.vm))
+	case "regs":
+		for i, name := range registerNames {
+			fmt.Fprintf(d.out, "%s=%d ", name, d.vm.registers[i])
+		}
+		fmt.Fprintf(d.out, "pc=%d cycles=%d\n", d.vm.pc, d.vm.cycle)
+	case "list", "l":
+		d.list()
+	case "trace":
+		if len(args) != 2 || args[1] != "on" && args[1] != "off" {
+			return fmt.Errorf("usage: trace on|off")
+		}
+		d.vm.trace = nil
+		if args[1] == "on" {
+			d.vm.trace = d.out
+		}
+	case "reset":
+		d.vm.reset()
+		d.updateWatches()
+		d.status()
+	default:
+		return fmt.Errorf("unknown command %q", args[0])
+	}
+	return nil
+}
+
+func (d *Debugger) delete(args []string) error {
+	if len(args) != 2 {
+		return fmt.Errorf("usage: delete break|watch N")
+	}
+	n, err := strconv.Atoi(args[1])
+	if err != nil {
+		return err
+	}
+
+	switch {
+	case args[0] == "break" && n >= 1 && n <= len(d.breakpoints):
+		d.breakpoints[n-1] = nil
+	case args[0] == "watch" && n >= 1 && n <= len(d.watches):
+		d.watches[n-1] = nil
+	default:
+		return fmt.Errorf("no %s %d", args[0], n)
+	}
+	return nil
+}
+
+func (d *Debugger) resume() {
+	for d.vm.stepCycle() {
+		if d.updateWatches() {
+			d.status()
+			return
+		}
+		for i, breakpoint := range d.breakpoints {
+			if breakpoint != nil && breakpoint.hit(d.vm) {
+				fmt.Fprintf(d.out, "breakpoint %d: %s %s\n", i+1, breakpoint.kind, breakpoint.value)
+				d.status()
+				return
+			}
+		}
+	}
+	d.status()
+}
+
+func (d *Debugger) updateWatches() bool {
+	changed := false
+	for i, watch := range d.watches {
+		if watch == nil {
+			continue
+		}
+		if value := watch.expr.eval(d.vm); value != watch.value {
+			fmt.Fprintf(d.out, "watch %d: %s: %d -> %d\n", i+1, watch.expr.text, watch.value, value)
+			watch.value = value
+			changed = true
+		}
+	}
+	return changed
+}
+
+func (d *Debugger) status() {
+	if d.vm.halted() {
+		fmt.Fprintf(d.out, "halted after %d cycles, x=%d\n", d.vm.cycle, d.vm.registers[0])
+		return
+	}
+	if d.vm.limitReached() {
+		fmt.Fprintf(d.out, "cycle limit %d reached, x=%d, pc=%d\n",
+			d.vm.maxCycles, d.vm.registers[0], d.vm.pc)
+		return
+	}
+
+	instruction := d.vm.current()
+	fmt.Fprintf(d.out, "before cycle %d, x=%d, pc=%d (line %d): %s [%d/%d]\n",
+		d.vm.cycle+1, d.vm.registers[0], d.vm.pc, instruction.line,
+		instruction, d.vm.elapsed, instruction.opcode.cycles)
+}
+
+func (d *Debugger) list() {
+	start := d.vm.pc - 3
+	if start < 0 {
+		start = 0
+	}
+	for i := start; i < len(d.vm.program) && i < start+7; i++ {
+		marker := "  "
+		if i == d.vm.pc {
+			marker = "=>"
+		}
+		fmt.Fprintf(d.out, "%s %4d  %s\n", marker, d.vm.program[i].line, d.vm.program[i])
+	}
+}
@@ -0,0 +1,73 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+)
+
+const (
+	glyphWidth   = 4
+	glyphHeight  = 6
+	glyphSpacing = 1
+)
+
+var font = map[string]byte{
+	".##.#..##..######..##..#": 'A',
+	"###.#..####.#..##..####.": 'B',
+	".##.#..##...#...#..#.##.": 'C',
+	"#####...###.#...#...####": 'E',
+	"#####...###.#...#...#...": 'F',
+	".##.#..##...#.###..#.###": 'G',
+	"#..##..######..##..##..#": 'H',
+	".###..#...#...#...#..###": 'I',
+	"..##...#...#...##..#.##.": 'J',
+	"#..##.#.##..#.#.#.#.#..#": 'K',
+	"#...#...#...#...#...####": 'L',
+	".##.#..##..##..##..#.##.": 'O',
+	"###.#..##..####.#...#...": 'P',
+	"###.#..##..####.#.#.#..#": 'R',
+	".####...#....##....####.": 'S',
+	"#..##..##..##..##..#.##.": 'U',
+	"#...#....#.#..#...#...#.": 'Y',
+	"####...#..#..#..#...####": 'Z',
+}
+
+type Glyph struct {
+	index  int
+	bitmap []string
+}
+
+func (g *Glyph) String() string {
+	return fmt.Sprintf("glyph %d:\n%s", g.index, strings.Join(g.bitmap, "\n"))
+}
+
+func decodeScreen(screen [][]byte) (string, []*Glyph, error) {
+	if len(screen) != glyphHeight {
+		return "", nil, fmt.Errorf("cannot decode a screen %d rows high with the %dx%d font",
+			len(screen), glyphWidth, glyphHeight)
+	}
+
+	var text strings.Builder
+	var unknown []*Glyph
+	for col, index := 0, 0; col+glyphWidth <= len(screen[0]); col, index = col+glyphWidth+glyphSpacing, index+1 {
+		bitmap := make([]string, glyphHeight)
+		for row := range bitmap {
+			bitmap[row] = string(screen[row][col : col+glyphWidth])
+		}
+
+		key := strings.Join(bitmap, "")
+		if key == strings.Repeat(".", glyphWidth*glyphHeight) {
+			text.WriteByte(' ')
+			continue
+		}
+
+		letter, found := font[key]
+		if !found {
+			letter = '?'
+			unknown = append(unknown, &Glyph{index, bitmap})
+		}
+		text.WriteByte(letter)
+	}
+
+	return strings.TrimRight(text.String(), " "), unknown, nil
+}
@@ -0,0 +1,286 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+var registerNames = []string{"x", "a", "b", "c", "d"}
+
+func registerIndex(name string) (int, bool) {
+	for i, register := range registerNames {
+		if register == name {
+			return i, true
+		}
+	}
+	return 0, false
+}
+
+type OperandKind int
+
+const (
+	Register OperandKind = iota
+	Value
+	Label
+)
+
+type Operand struct {
+	kind  OperandKind
+	value int
+	text  string
+}
+
+func (o Operand) resolve(vm *VM) int {
+	if o.kind == Register {
+		return vm.registers[o.value]
+	}
+	return o.value
+}
+
+type Opcode struct {
+	name     string
+	cycles   int
+	operands []OperandKind
+	exec     func(vm *VM, operands []Operand)
+}
+
+var opcodes = map[string]*Opcode{
+	"noop": {"noop", 1, nil, func(vm *VM, operands []Operand) {}},
+	"addx": {"addx", 2, []OperandKind{Value}, func(vm *VM, operands []Operand) {
+		vm.registers[0] += operands[0].resolve(vm)
+	}},
+	"mov": {"mov", 1, []OperandKind{Register, Value}, func(vm *VM, operands []Operand) {
+		vm.registers[operands[0].value] = operands[1].resolve(vm)
+	}},
+	"add": {"add", 2, []OperandKind{Register, Value}, func(vm *VM, operands []Operand) {
+		vm.registers[operands[0].value] += operands[1].resolve(vm)
+	}},
+	"sub": {"sub", 2, []OperandKind{Register, Value}, func(vm *VM, operands []Operand) {
+		vm.registers[operands[0].value] -= operands[1].resolve(vm)
+	}},
+	"mul": {"mul", 3, []OperandKind{Register, Value}, func(vm *VM, operands []Operand) {
+		vm.registers[operands[0].value] *= operands[1].resolve(vm)
+	}},
+	"jmp": {"jmp", 1, []OperandKind{Label}, func(vm *VM, operands []Operand) {
+		vm.next = operands[0].value
+	}},
+	"jz": {"jz", 1, []OperandKind{Register, Label}, func(vm *VM, operands []Operand) {
+		if operands[0].resolve(vm) == 0 {
+			vm.next = operands[1].value
+		}
+	}},
+	"jnz": {"jnz", 1, []OperandKind{Register, Label}, func(vm *VM, operands []Operand) {
+		if operands[0].resolve(vm) != 0 {
+			vm.next = operands[1].value
+		}
+	}},
+	"halt": {"halt", 1, nil, func(vm *VM, operands []Operand) {
+		vm.next = len(vm.program)
+	}},
+}
+
+type Instruction struct {
+	opcode   *Opcode
+	operands []Operand
+	line     int
+}
+
+func (i *Instruction) String() string {
+	parts := []string{i.opcode.name}
+	for _, operand := range i.operands {
+		parts = append(parts, operand.text)
+	}
+	return strings.Join(parts, " ")
+}
+
+func assemble(r io.Reader) ([]*Instruction, error) {
+	type pending struct {
+		operand *Operand
+		line    int
+	}
+
+	var program []*Instruction
+	var labelRefs []pending
+	labels := make(map[string]int)
+
+	scanner := bufio.NewScanner(r)
+	for line := 1; scanner.Scan(); line++ {
+		text := scanner.Text()
+		if idx := strings.IndexAny(text, ";#"); idx >= 0 {
+			text = text[:idx]
+		}
+
+		fields := strings.Fields(strings.ReplaceAll(text, ",", " "))
+		for len(fields) > 0 && strings.HasSuffix(fields[0], ":") {
+			label := strings.TrimSuffix(fields[0], ":")
+			if _, found := labels[label]; found {
+				return nil, fmt.Errorf("line %d: duplicate label %q", line, label)
+			}
+			labels[label] = len(program)
+			fields = fields[1:]
+		}
+		if len(fields) == 0 {
+			continue
+		}
+
+		opcode, found := opcodes[fields[0]]
+		if !found {
+			return nil, fmt.Errorf("line %d: unknown instruction %q", line, fields[0])
+		}
+		if len(fields)-1 != len(opcode.operands) {
+			return nil, fmt.Errorf("line %d: %s expects %d operands, got %d",
+				line, opcode.name, len(opcode.operands), len(fields)-1)
+		}
+
+		instruction := &Instruction{opcode, make([]Operand, len(opcode.operands)), line}
+		for i, kind := range opcode.operands {
+			operand, err := parseOperand(fields[i+1], kind)
+			if err != nil {
+				return nil, fmt.Errorf("line %d: %w", line, err)
+			}
+			instruction.operands[i] = operand
+		}
+		program = append(program, instruction)
+
+		for i := range instruction.operands {
+			if instruction.operands[i].kind == Label {
+				labelRefs = append(labelRefs, pending{&instruction.operands[i], line})
+			}
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	for _, ref := range labelRefs {
+		target, found := labels[ref.operand.text]
+		if !found {
+			return nil, fmt.Errorf("line %d: undefined label %q", ref.line, ref.operand.text)
+		}
+		ref.operand.value = target
+	}
+
+	return program, nil
+}
+
+func parseOperand(text string, kind OperandKind) (Operand, error) {
+	switch kind {
+	case Register:
+		idx, found := registerIndex(text)
+		if !found {
+			return Operand{}, fmt.Errorf("unknown register %q", text)
+		}
+		return Operand{Register, idx, text}, nil
+	case Label:
+		return Operand{Label, 0, text}, nil
+	default:
+		if idx, found := registerIndex(text); found {
+			return Operand{Register, idx, text}, nil
+		}
+		n, err := strconv.Atoi(text)
+		if err != nil {
+			return Operand{}, fmt.Errorf("invalid operand %q", text)
+		}
+		return Operand{Value, n, text}, nil
+	}
+}
+
+type Device interface {
+	tick(vm *VM)
+	reset()
+}
+
+type VM struct {
+	program   []*Instruction
+	registers []int
+	pc        int
+	next      int
+	cycle     int
+	elapsed   int
+	devices   []Device
+	maxCycles int
+	trace     io.Writer
+}
+
+func newVM(program []*Instruction, devices ...Device) *VM {
+	vm := &VM{program: program, devices: devices}
+	vm.reset()
+	return vm
+}
+
+func (vm *VM) reset() {
+	vm.registers = make([]int, len(registerNames))
+	vm.registers[0] = 1
+	vm.pc, vm.cycle, vm.elapsed = 0, 0, 0
+	for _, device := range vm.devices {
+		device.reset()
+	}
+}
+
+func (vm *VM) halted() bool {
+	return vm.pc >= len(vm.program)
+}
+
+func (vm *VM) limitReached() bool {
+	return vm.maxCycles > 0 && vm.cycle >= vm.maxCycles
+}
+
+func (vm *VM) current() *Instruction {
+	if vm.halted() {
+		return nil
+	}
+	return vm.program[vm.pc]
+}
+
+func (vm *VM) stepCycle() bool {
+	if vm.halted() || vm.limitReached() {
+		return false
+	}
+
+	instruction := vm.program[vm.pc]
+	vm.cycle++
+	vm.elapsed++
+	if vm.trace != nil {
+		vm.writeTrace(instruction)
+	}
+	for _, device := range vm.devices {
+		device.tick(vm)
+	}
+
+	if vm.elapsed == instruction.opcode.cycles {
+		vm.next = vm.pc + 1
+		instruction.opcode.exec(vm, instruction.operands)
+		vm.pc, vm.elapsed = vm.next, 0
+	}
+	return true
+}
+
+func (vm *VM) stepInstruction() bool {
+	for vm.stepCycle() {
+		if vm.elapsed == 0 {
+			return true
+		}
+	}
+	return false
+}
+
+func (vm *VM) run() error {
+	for vm.stepCycle() {
+	}
+	if !vm.halted() {
+		return fmt.Errorf("program did not halt within %d cycles", vm.maxCycles)
+	}
+	return nil
+}
+
+func (vm *VM) writeTrace(instruction *Instruction) {
+	fmt.Fprintf(vm.trace, "cycle=%d pc=%d line=%d %d/%d %-12s",
+		vm.cycle, vm.pc, instruction.line, vm.elapsed, instruction.opcode.cycles, instruction)
+	for i, name := range registerNames {
+		fmt.Fprintf(vm.trace, " %s=%d", name, vm.registers[i])
+	}
+	fmt.Fprintln(vm.trace)
+}
@@ -0,0 +1,199 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"unicode"
+)
+
+type Expr interface {
+	eval(old Worry, policy ReliefPolicy) (Worry, error)
+	String() string
+}
+
+type OldExpr struct{}
+
+func (e *OldExpr) eval(old Worry, policy ReliefPolicy) (Worry, error) {
+	return old, nil
+}
+
+func (e *OldExpr) String() string {
+	return "old"
+}
+
+type ConstExpr struct {
+	value int
+}
+
+func (e *ConstExpr) eval(old Worry, policy ReliefPolicy) (Worry, error) {
+	return policy.newLevel(e.value), nil
+}
+
+func (e *ConstExpr) String() string {
+	return strconv.Itoa(e.value)
+}
+
+type BinaryExpr struct {
+	operator byte
+	left     Expr
+	right    Expr
+}
+
+func (e *BinaryExpr) eval(old Worry, policy ReliefPolicy) (Worry, error) {
+	left, err := e.left.eval(old, policy)
+	if err != nil {
+		return nil, err
+	}
+	right, err := e.right.eval(old, policy)
+	if err != nil {
+		return nil, err
+	}
+
+	switch e.operator {
+	case '+':
+		return left.Add(right), nil
+	case '-':
+		return left.Sub(right), nil
+	case '*':
+		return left.Mul(right), nil
+	case '/':
+		return left.Div(right)
+	default:
+		return left.Mod(right)
+	}
+}
+
+func (e *BinaryExpr) String() string {
+	return fmt.Sprintf("(%s %c %s)", e.left, e.operator, e.right)
+}
+
+type exprParser struct {
+	text string
+	pos  int
+}
+
+func parseExpr(text string) (Expr, error) {
+	p := &exprParser{text: text}
+	expr, err := p.parseSum()
+	if err != nil {
+		return nil, err
+	}
+	if p.skipSpaces(); p.pos < len(p.text) {
+		return nil, p.errorf("unexpected %q", p.text[p.pos])
+	}
+	return expr, nil
+}
+
+func (p *exprParser) errorf(format string, args ...any) error {
+	return fmt.Errorf("column %d: %s", p.pos+1, fmt.Sprintf(format, args...))
+}
+
+func (p *exprParser) skipSpaces() {
+	for p.pos < len(p.text) && p.text[p.pos] == ' ' {
+		p.pos++
+	}
+}
+
+func (p *exprParser) accept(operators string) (byte, bool) {
+	p.skipSpaces()
+	if p.pos < len(p.text) {
+		for i := 0; i < len(operators); i++ {
+			if p.text[p.pos] == operators[i] {
+				p.pos++
+				return operators[i], true
+			}
+		}
+	}
+	return 0, false
+}
+
+func (p *exprParser) parseSum() (Expr, error) {
+	left, err := p.parseProduct()
+	if err != nil {
+		return nil, err
+	}
+
+	for {
+		operator, ok := p.accept("+-")
+		if !ok {
+			return left, nil
+		}
+		right, err := p.parseProduct()
+		if err != nil {
+			return nil, err
+		}
+		left = &BinaryExpr{operator, left, right}
+	}
+}
+
+func (p *exprParser) parseProduct() (Expr, error) {
+	left, err := p.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+
+	for {
+		operator, ok := p.accept("*/%")
+		if !ok {
+			return left, nil
+		}
+		right, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		left = &BinaryExpr{operator, left, right}
+	}
+}
+
+func (p *exprParser) parseUnary() (Expr, error) {
+	if _, ok := p.accept("-"); ok {
+		operand, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		return &BinaryExpr{'-', &ConstExpr{0}, operand}, nil
+	}
+	return p.parsePrimary()
+}
+
+func (p *exprParser) parsePrimary() (Expr, error) {
+	if _, ok := p.accept("("); ok {
+		expr, err := p.parseSum()
+		if err != nil {
+			return nil, err
+		}
+		if _, ok := p.accept(")"); !ok {
+			return nil, p.errorf("expected ')'")
+		}
+		return expr, nil
+	}
+
+	p.skipSpaces()
+	start := p.pos
+	for p.pos < len(p.text) && unicode.IsDigit(rune(p.text[p.pos])) {
+		p.pos++
+	}
+	if p.pos > start {
+		value, err := strconv.Atoi(p.text[start:p.pos])
+		if err != nil {
+			return nil, p.errorf("%v", err)
+		}
+		return &ConstExpr{value}, nil
+	}
+
+	for p.pos < len(p.text) && unicode.IsLetter(rune(p.text[p.pos])) {
+		p.pos++
+	}
+	switch word := p.text[start:p.pos]; word {
+	case "old":
+		return &OldExpr{}, nil
+	case "":
+		if p.pos == len(p.text) {
+			return nil, p.errorf("unexpected end of expression")
+		}
+		return nil, p.errorf("unexpected %q", p.text[p.pos])
+	default:
+		p.pos = start
+		return nil, p.errorf("unknown variable %q", word)
+	}
+}
@@ -0,0 +1,235 @@
+package main
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+type Monkey struct {
+	startingItems  []int
+	items          []Worry
+	operation      Expr
+	divisor        int
+	ifTrue         int
+	ifFalse        int
+	timesInspected int
+}
+
+func (m *Monkey) turn(monkeys []*Monkey, policy ReliefPolicy) error {
+	for _, item := range m.items {
+		item, err := m.operation.eval(item, policy)
+		if err != nil {
+			return fmt.Errorf("new = %s: %w", m.operation, err)
+		}
+		item = policy.relieve(item)
+		if m.test(item) {
+			monkeys[m.ifTrue].catch(item)
+		} else {
+			monkeys[m.ifFalse].catch(item)
+		}
+	}
+
+	m.timesInspected += len(m.items)
+	m.items = m.items[:0]
+	return nil
+}
+
+func (m *Monkey) test(item Worry) bool {
+	return item.DivisibleBy(m.divisor)
+}
+
+func (m *Monkey) catch(item Worry) {
+	m.items = append(m.items, item)
+}
+
+var numberRegex *regexp.Regexp = regexp.MustCompile("[0-9]+")
+var opRegex *regexp.Regexp = regexp.MustCompile("new =.*")
+
+var regexps [5]*regexp.Regexp = [5]*regexp.Regexp{
+	numberRegex,
+	opRegex,
+	numberRegex,
+	numberRegex,
+	numberRegex,
+}
+
+func parseMonkey(scanner *bufio.Scanner) *Monkey {
+	var lines [5]string
+	var attributes [5][]string
+	for i, regex := range regexps {
+		scanner.Scan()
+		lines[i] = scanner.Text()
+		attributes[i] = regex.FindAllString(lines[i], -1)
+	}
+	scanner.Scan()
+
+	startingItems := make([]int, len(attributes[0]))
+	for i, itemStr := range attributes[0] {
+		item, err := strconv.Atoi(itemStr)
+		errorHandler(err)
+		startingItems[i] = item
+	}
+
+	divisor, err := strconv.Atoi(attributes[2][0])
+	errorHandler(err)
+	if divisor < 1 {
+		log.Fatalf("invalid divisor %d in %q", divisor, lines[2])
+	}
+	ifTrue, err := strconv.Atoi(attributes[3][0])
+	errorHandler(err)
+	ifFalse, err := strconv.Atoi(attributes[4][0])
+	errorHandler(err)
+	if len(attributes[1]) == 0 {
+		log.Fatalf("missing operation in %q", lines[1])
+	}
+	operation, err := parseExpr(strings.TrimSpace(strings.TrimPrefix(attributes[1][0], "new =")))
+	errorHandler(err)
+
+	return &Monkey{
+		startingItems:  startingItems,
+		operation:      operation,
+		divisor:        divisor,
+		ifTrue:         ifTrue,
+		ifFalse:        ifFalse,
+		timesInspected: 0,
+	}
+}
+
+func getDivisors(monkeys []*Monkey) []int {
+	divisors := make([]int, len(monkeys))
+	for i, monkey := range monkeys {
+		divisors[i] = monkey.divisor
+	}
+	return divisors
+}
+
+func setItems(monkeys []*Monkey, policy ReliefPolicy) {
+	for _, monkey := range monkeys {
+		monkey.items = monkey.items[:0]
+		monkey.timesInspected = 0
+		for _, item := range monkey.startingItems {
+			monkey.items = append(monkey.items, policy.newLevel(item))
+		}
+	}
+}
+
+func round(monkeys []*Monkey, policy ReliefPolicy) error {
+	for _, monkey := range monkeys {
+		if err := monkey.turn(monkeys, policy); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func getMonkeyBusiness(monkeys []*Monkey) int64 {
+	timesInspected := make([]int, len(monkeys))
+	for i, monkey := range monkeys {
+		timesInspected[i] = monkey.timesInspected
+	}
+	sort.Sort(sort.Reverse(sort.IntSlice(timesInspected)))
+
+	var monkeyBusiness int64 = 1
+	if len(timesInspected) > 2 {
+		timesInspected = timesInspected[:2]
+	}
+	for _, times := range timesInspected {
+		monkeyBusiness *= int64(times)
+	}
+
+	return monkeyBusiness
+}
+
+func validateOperations(monkeys []*Monkey, policy ReliefPolicy) error {
+	for i, monkey := range monkeys {
+		if err := policy.validate(monkey.operation); err != nil {
+			return fmt.Errorf("monkey %d: new = %s: %w", i, monkey.operation, err)
+		}
+	}
+	return nil
+}
+
+func simulate(monkeys []*Monkey, policy ReliefPolicy, rounds int) (int64, error) {
+	setItems(monkeys, policy)
+	for i := 0; i < rounds; i++ {
+		if err := round(monkeys, policy); err != nil {
+			return 0, fmt.Errorf("round %d: %w", i+1, err)
+		}
+	}
+	return getMonkeyBusiness(monkeys), nil
+}
+
+func parseRounds(text string, n int) ([]int, error) {
+	fields := strings.Split(text, ",")
+	if len(fields) != 1 && len(fields) != n {
+		return nil, fmt.Errorf("got %d round counts for %d relief policies", len(fields), n)
+	}
+
+	rounds := make([]int, n)
+	for i := range rounds {
+		field := fields[0]
+		if len(fields) == n {
+			field = fields[i]
+		}
+		count, err := strconv.Atoi(field)
+		if err != nil {
+			return nil, err
+		}
+		if count < 0 {
+			return nil, fmt.Errorf("negative round count %d", count)
+		}
+		rounds[i] = count
+	}
+	return rounds, nil
+}
+
+func main() {
+	relief := flag.String("relief", "divide,modular", "comma-separated relief policies, one answer each ("+strings.Join(reliefNames(), ", ")+")")
+	roundsText := flag.String("rounds", "20,10000", "round count for every policy, or one per policy")
+	divideBy := flag.Int("divide-by", 3, "worry divisor for the divide and exact policies")
+	flag.Parse()
+
+	if *divideBy < 1 {
+		log.Fatal("divide-by must be positive")
+	}
+	names := strings.Split(*relief, ",")
+	rounds, err := parseRounds(*roundsText, len(names))
+	errorHandler(err)
+
+	f, err := os.Open("input.txt")
+	errorHandler(err)
+	defer f.Close()
+
+	var monkeys []*Monkey
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		monkeys = append(monkeys, parseMonkey(scanner))
+	}
+
+	divisors := getDivisors(monkeys)
+	policies := make([]ReliefPolicy, len(names))
+	for i, name := range names {
+		policies[i], err = newReliefPolicy(name, *divideBy, divisors)
+		errorHandler(err)
+		errorHandler(validateOperations(monkeys, policies[i]))
+	}
+
+	for i, policy := range policies {
+		monkeyBusiness, err := simulate(monkeys, policy, rounds[i])
+		errorHandler(err)
+		fmt.Println(monkeyBusiness)
+	}
+}
+
+func errorHandler(err error) {
+	if err != nil {
+		log.Fatal(err)
+	}
+}
@@ -0,0 +1,233 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	"sort"
+	"strings"
+)
+
+type Worry interface {
+	Add(other Worry) Worry
+	Sub(other Worry) Worry
+	Mul(other Worry) Worry
+	Div(other Worry) (Worry, error)
+	Mod(other Worry) (Worry, error)
+	DivisibleBy(divisor int) bool
+}
+
+type PlainLevel struct {
+	value int
+}
+
+func (l *PlainLevel) Add(other Worry) Worry {
+	return &PlainLevel{l.value + other.(*PlainLevel).value}
+}
+
+func (l *PlainLevel) Sub(other Worry) Worry {
+	return &PlainLevel{l.value - other.(*PlainLevel).value}
+}
+
+func (l *PlainLevel) Mul(other Worry) Worry {
+	return &PlainLevel{l.value * other.(*PlainLevel).value}
+}
+
+func (l *PlainLevel) Div(other Worry) (Worry, error) {
+	divisor := other.(*PlainLevel).value
+	if divisor == 0 {
+		return nil, errors.New("division by zero")
+	}
+	return &PlainLevel{l.value / divisor}, nil
+}
+
+func (l *PlainLevel) Mod(other Worry) (Worry, error) {
+	divisor := other.(*PlainLevel).value
+	if divisor == 0 {
+		return nil, errors.New("modulo by zero")
+	}
+	return &PlainLevel{l.value % divisor}, nil
+}
+
+func (l *PlainLevel) DivisibleBy(divisor int) bool {
+	return l.value%divisor == 0
+}
+
+type WorryLevel struct {
+	modulos map[int]int
+}
+
+func (w *WorryLevel) combine(other Worry, op func(a, b int) int) Worry {
+	modulos := make(map[int]int, len(w.modulos))
+	for divisor, modulo := range w.modulos {
+		result := op(modulo, other.(*WorryLevel).modulos[divisor]) % divisor
+		modulos[divisor] = (result + divisor) % divisor
+	}
+	return &WorryLevel{modulos}
+}
+
+func (w *WorryLevel) Add(other Worry) Worry {
+	return w.combine(other, func(a, b int) int { return a + b })
+}
+
+func (w *WorryLevel) Sub(other Worry) Worry {
+	return w.combine(other, func(a, b int) int { return a - b })
+}
+
+func (w *WorryLevel) Mul(other Worry) Worry {
+	return w.combine(other, func(a, b int) int { return a * b })
+}
+
+func (w *WorryLevel) Div(other Worry) (Worry, error) {
+	return nil, errors.New("division cannot be kept modular")
+}
+
+func (w *WorryLevel) Mod(other Worry) (Worry, error) {
+	return nil, errors.New("modulo cannot be kept modular")
+}
+
+func (w *WorryLevel) DivisibleBy(divisor int) bool {
+	return w.modulos[divisor] == 0
+}
+
+type BigLevel struct {
+	value *big.Int
+}
+
+func (l *BigLevel) Add(other Worry) Worry {
+	return &BigLevel{new(big.Int).Add(l.value, other.(*BigLevel).value)}
+}
+
+func (l *BigLevel) Sub(other Worry) Worry {
+	return &BigLevel{new(big.Int).Sub(l.value, other.(*BigLevel).value)}
+}
+
+func (l *BigLevel) Mul(other Worry) Worry {
+	return &BigLevel{new(big.Int).Mul(l.value, other.(*BigLevel).value)}
+}
+
+func (l *BigLevel) Div(other Worry) (Worry, error) {
+	divisor := other.(*BigLevel).value
+	if divisor.Sign() == 0 {
+		return nil, errors.New("division by zero")
+	}
+	return &BigLevel{new(big.Int).Quo(l.value, divisor)}, nil
+}
+
+func (l *BigLevel) Mod(other Worry) (Worry, error) {
+	divisor := other.(*BigLevel).value
+	if divisor.Sign() == 0 {
+		return nil, errors.New("modulo by zero")
+	}
+	return &BigLevel{new(big.Int).Rem(l.value, divisor)}, nil
+}
+
+func (l *BigLevel) DivisibleBy(divisor int) bool {
+	var modulo big.Int
+	return modulo.Rem(l.value, big.NewInt(int64(divisor))).Sign() == 0
+}
+
+type ReliefPolicy interface {
+	newLevel(item int) Worry
+	relieve(level Worry) Worry
+	validate(operation Expr) error
+}
+
+type DivideRelief struct {
+	k int
+}
+
+func (r *DivideRelief) newLevel(item int) Worry {
+	return &PlainLevel{item}
+}
+
+func (r *DivideRelief) relieve(level Worry) Worry {
+	return &PlainLevel{level.(*PlainLevel).value / r.k}
+}
+
+func (r *DivideRelief) validate(operation Expr) error {
+	return nil
+}
+
+type ModularRelief struct {
+	divisors []int
+}
+
+func (r *ModularRelief) newLevel(item int) Worry {
+	modulos := make(map[int]int, len(r.divisors))
+	for _, divisor := range r.divisors {
+		modulos[divisor] = (item%divisor + divisor) % divisor
+	}
+	return &WorryLevel{modulos}
+}
+
+func (r *ModularRelief) relieve(level Worry) Worry {
+	return level
+}
+
+func (r *ModularRelief) validate(operation Expr) error {
+	binary, isBinary := operation.(*BinaryExpr)
+	if !isBinary {
+		return nil
+	}
+
+	switch binary.operator {
+	case '/':
+		return errors.New("division cannot be kept modular")
+	case '%':
+		return errors.New("modulo cannot be kept modular")
+	}
+	if err := r.validate(binary.left); err != nil {
+		return err
+	}
+	return r.validate(binary.right)
+}
+
+type ExactRelief struct {
+	k *big.Int
+}
+
+func (r *ExactRelief) newLevel(item int) Worry {
+	return &BigLevel{big.NewInt(int64(item))}
+}
+
+func (r *ExactRelief) relieve(level Worry) Worry {
+	if r.k.Cmp(big.NewInt(1)) > 0 {
+		return &BigLevel{new(big.Int).Quo(level.(*BigLevel).value, r.k)}
+	}
+	return level
+}
+
+func (r *ExactRelief) validate(operation Expr) error {
+	return nil
+}
+
+var reliefPolicies = map[string]func(k int, divisors []int) ReliefPolicy{
+	"divide": func(k int, divisors []int) ReliefPolicy {
+		return &DivideRelief{k}
+	},
+	"modular": func(k int, divisors []int) ReliefPolicy {
+		return &ModularRelief{divisors}
+	},
+	"exact": func(k int, divisors []int) ReliefPolicy {
+		return &ExactRelief{big.NewInt(int64(k))}
+	},
+}
+
+func reliefNames() []string {
+	var names []string
+	for name := range reliefPolicies {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func newReliefPolicy(name string, k int, divisors []int) (ReliefPolicy, error) {
+	factory, ok := reliefPolicies[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown relief policy %q (available: %s)",
+			name, strings.Join(reliefNames(), ", "))
+	}
+	return factory(k, divisors), nil
+}